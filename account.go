@@ -1,50 +1,26 @@
 package tdam
 
 import (
-	"crypto/tls"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"net/url"
 )
 
 func (c *Client) GetAccounts() ([]*Account, error) {
-	token, err := c.TDAMToken()
-	if err != nil {
-		return nil, err
-	}
-
-	transport := &http.Transport{TLSClientConfig: &tls.Config{}}
-	client := &http.Client{Transport: transport}
-
-	req, err := http.NewRequest("GET", "https://api.tdameritrade.com/v1/accounts", nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	return c.GetAccountsContext(context.Background())
+}
 
-	query := req.URL.Query()
+func (c *Client) GetAccountsContext(ctx context.Context) ([]*Account, error) {
+	query := url.Values{}
 	query.Add("fields", "positions")
 	query.Add("fields", "orders")
-	req.URL.RawQuery = query.Encode()
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != 200 {
-		fmt.Printf("status %d: %s\n", resp.StatusCode, resp.Status)
-		body, _ := ioutil.ReadAll(resp.Body)
-		fmt.Println(string(body))
-	}
-	defer resp.Body.Close()
-
-	/*
-		dump, err := httputil.DumpResponse(resp, true)
-		fmt.Printf("%s\n", dump)
-	*/
 
 	var accounts []*Account
-	if err := json.NewDecoder(resp.Body).Decode(&accounts); err != nil {
+	if err := c.DoJSONContext(ctx, Request{
+		Endpoint:      "/v1/accounts",
+		Query:         query,
+		Authenticated: true,
+	}, &accounts); err != nil {
 		return nil, err
 	}
 
@@ -17,11 +17,19 @@ const (
 	INDEX           InstrumentType = "INDEX"
 	FIXED_INCOME    InstrumentType = "FIXED_INCOME"
 	CURRENCY        InstrumentType = "CURRENCY"
+	ETF             InstrumentType = "ETF"
+	FUTURE          InstrumentType = "FUTURE"
+	FOREX           InstrumentType = "FOREX"
+	BOND            InstrumentType = "BOND"
 )
 
 type Account struct {
 	*Client
 	SecuritiesAccount `json:"securitiesAccount"`
+
+	// not part of the account response, it comes from the user principal's
+	// authorizations, see user.UserPrincipal.Authorize.  Empty skips the check.
+	OptionTradingLevel OptionTradingLevel `json:"-"`
 }
 
 type SecuritiesAccount struct {
@@ -59,40 +67,46 @@ func (a SecuritiesAccount) Positions() map[Symbol][]*Position {
 
 type Position struct {
 	ShortQuantity                  float64     `json:"shortQuantity"`
-	AveragePrice                   float64     `json:"averagePrice"`
-	CurrentDayProfitLoss           float64     `json:"currentDayProfitLoss"`
+	AveragePrice                   Money       `json:"averagePrice"`
+	CurrentDayProfitLoss           Money       `json:"currentDayProfitLoss"`
 	CurrentDayProfitLossPercentage float64     `json:"currentDayProfitLossPercentage"`
 	LongQuantity                   float64     `json:"longQuantity"`
 	SettledLongQuantity            float64     `json:"settledLongQuantity"`
 	SettledShortQuantity           float64     `json:"settledShortQuantity"`
 	AgedQuantity                   float64     `json:"agedQuantity"`
 	Instrument                     *Instrument `json:"instrument"`
-	MarketValue                    float64     `json:"marketValue"`
+	MarketValue                    Money       `json:"marketValue"`
 }
 
 func (p Position) String() string {
-	return fmt.Sprintf("%g %s %.2f", p.LongQuantity-p.ShortQuantity, p.Instrument, p.MarketValue)
+	return fmt.Sprintf("%g %s %s", p.LongQuantity-p.ShortQuantity, p.Instrument, p.MarketValue)
 }
 
 type Balances struct {
-	AccruedInterest              float64 `json:"accruedInterest"`
-	CashBalance                  float64 `json:"cashBalance"`
-	CashReceipts                 float64 `json:"cashReceipts"`
-	LongOptionMarketValue        float64 `json:"longOptionMarketValue"`
-	LiquidationValue             float64 `json:"liquidationValue"`
-	LongMarketValue              float64 `json:"longMarketValue"`
-	MoneyMarketFund              float64 `json:"moneyMarketFund"`
-	Savings                      float64 `json:"savings"`
-	ShortMarketValue             float64 `json:"shortMarketValue"`
-	PendingDeposits              float64 `json:"pendingDeposits"`
-	CashAvailableForTrading      float64 `json:"cashAvailableForTrading"`
-	CashAvailableForWithdrawal   float64 `json:"cashAvailableForWithdrawal"`
-	CashCall                     float64 `json:"cashCall"`
-	LongNonMarginableMarketValue float64 `json:"longNNMarginableMarketValue"`
-	TotalCash                    float64 `json:"totalCash"`
-	ShortOptionMarketValue       float64 `json:"shortOptionMarketValue"`
-	MutualFundValue              float64 `json:"mutualFundValue"`
-	BondValue                    float64 `json:"bondValue"`
-	CashDebitCallValue           float64 `json:"cashDebitCallValue"`
-	UnsettledCash                float64 `json:"unsettledCash"`
+	AccruedInterest              Money `json:"accruedInterest"`
+	CashBalance                  Money `json:"cashBalance"`
+	CashReceipts                 Money `json:"cashReceipts"`
+	LongOptionMarketValue        Money `json:"longOptionMarketValue"`
+	LiquidationValue             Money `json:"liquidationValue"`
+	LongMarketValue              Money `json:"longMarketValue"`
+	MoneyMarketFund              Money `json:"moneyMarketFund"`
+	Savings                      Money `json:"savings"`
+	ShortMarketValue             Money `json:"shortMarketValue"`
+	PendingDeposits              Money `json:"pendingDeposits"`
+	CashAvailableForTrading      Money `json:"cashAvailableForTrading"`
+	CashAvailableForWithdrawal   Money `json:"cashAvailableForWithdrawal"`
+	CashCall                     Money `json:"cashCall"`
+	LongNonMarginableMarketValue Money `json:"longNNMarginableMarketValue"`
+	TotalCash                    Money `json:"totalCash"`
+	ShortOptionMarketValue       Money `json:"shortOptionMarketValue"`
+	MutualFundValue              Money `json:"mutualFundValue"`
+	BondValue                    Money `json:"bondValue"`
+	CashDebitCallValue           Money `json:"cashDebitCallValue"`
+	UnsettledCash                Money `json:"unsettledCash"`
+
+	// margin accounts only
+	AvailableFunds        Money `json:"availableFunds,omitempty"`
+	BuyingPower           Money `json:"buyingPower,omitempty"`
+	DayTradingBuyingPower Money `json:"dayTradingBuyingPower,omitempty"`
+	Equity                Money `json:"equity,omitempty"`
 }
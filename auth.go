@@ -2,109 +2,134 @@ package tdam
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
-	"strings"
-	"sync"
 	"time"
-
-	"gopkg.in/headzoo/surf.v1"
 )
 
-var tokenEndpoint string = "https://api.tdameritrade.com/v1/oauth2/token"
-
-var tdamToken *TokenResponse
-
-// TODO: this sets the token globally!
-// eventually, this will need to be stored in a user session
-// so multiple people can use the app with their own authentication
+// SetToken stores token as the client's current token and saves it to
+// the client's TokenStore.  A token without a refresh token (as returned
+// from a refresh grant) keeps the previously stored refresh token.
 func (c *Client) SetToken(token *TokenResponse) error {
-	// store the access token (bearer token) in memory,
-	// write the refresh token to disk to use on subsequent runs
-	tdamToken = token
-
-	configDir, err := getConfigDir()
-	if err != nil {
-		return err
+	if token == nil {
+		return fmt.Errorf("can't set a nil token")
 	}
+	token = copyToken(token)
 
-	if err := os.MkdirAll(configDir, os.ModeDir&os.ModePerm); err != nil {
-		return err
-	}
+	c.tokenMu.Lock()
+	token.keepRefreshToken(c.token)
+	c.token = token
+	c.tokenMu.Unlock()
 
-	f, err := os.Create(path.Join(configDir, "tdam_refresh"))
+	store, err := c.tokenStore()
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	_, err = f.Write([]byte(token.RefreshToken))
-	return err
+	return store.SaveToken(token)
+}
+
+func (c *Client) currentToken() *TokenResponse {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	return c.token
 }
 
-var authMutex sync.Mutex
+func (c *Client) tokenStore() (TokenStore, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	if c.TokenStore == nil {
+		store, err := defaultTokenStore()
+		if err != nil {
+			return nil, err
+		}
+		c.TokenStore = store
+	}
+	return c.TokenStore, nil
+}
 
 // gets the active access token if available
-// if not, looks for refresh token on disk and attempts to refresh
+// if not, looks for refresh token in the token store and attempts to refresh
 // if not, push through oauth flow
 func (c *Client) TDAMToken() (string, error) {
+	return c.TDAMTokenContext(context.Background())
+}
+
+// TDAMTokenContext is TDAMToken with cancellation of any token refresh request.
+func (c *Client) TDAMTokenContext(ctx context.Context) (string, error) {
 	if c == nil {
 		return "", fmt.Errorf("can't get a token with a nil client!")
 	}
 	// synchronizing this so we don't infinitely spawn browser windows
-	authMutex.Lock()
-	defer authMutex.Unlock()
-	if tdamToken != nil && tdamToken.AccessExpiry.After(time.Now()) {
-		return tdamToken.AccessToken, nil
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	if t := c.currentToken(); t != nil && t.AccessExpiry.After(time.Now()) {
+		return t.AccessToken, nil
 	}
-	//fmt.Printf("Access token missing or expired, checking refresh\n")
-	refresh, err := getStoredRefreshToken()
-	if err != nil || refresh == "" {
-		fmt.Printf("Error fetching refresh token\n")
 
-		err := c.slogThroughOauthFlow()
-		if err != nil {
-			fmt.Println(err)
-			return "", err
+	store, err := c.tokenStore()
+	if err != nil {
+		return "", err
+	}
+	//fmt.Printf("Access token missing or expired, checking refresh\n")
+	stored, err := store.LoadToken()
+	if err != nil {
+		return "", fmt.Errorf("loading token: %w", err)
+	}
+	if stored == nil || stored.RefreshToken == "" {
+		if c.noLoginFlow {
+			return "", ErrNoToken
+		}
+		if err := c.slogThroughOauthFlow(ctx); err != nil {
+			return "", fmt.Errorf("no refresh token, logging in: %w", err)
+		}
+		// the oauth callback saves the new token to the store
+		if stored, err = store.LoadToken(); err != nil {
+			return "", fmt.Errorf("loading token: %w", err)
 		}
+		if stored == nil {
+			return "", ErrNoToken
+		}
+	}
+	if stored.AccessExpiry.After(time.Now()) {
+		c.tokenMu.Lock()
+		c.token = stored
+		c.tokenMu.Unlock()
+		return stored.AccessToken, nil
 	}
-	tdamToken, err = c.refreshToken(refresh)
+
+	token, err := c.refreshToken(ctx, stored.RefreshToken)
 	if err != nil {
-		fmt.Printf("Error refreshing token: %s\n", err)
-		fmt.Printf("To obtain a new token, visit %s\n", c.TdamAuthURL())
-		//exec.Command("open", tdamAuthURL()).Run()
-		//time.Sleep(10 * time.Second)
+		return "", &TokenRefreshError{Err: err, AuthURL: c.TdamAuthURL()}
+	}
+	token.keepRefreshToken(stored)
+	if err := c.SetToken(token); err != nil {
 		return "", err
 	}
 
-	return tdamToken.AccessToken, nil
+	return token.AccessToken, nil
 }
 
 func (c *Client) TdamAuthURL() string {
-	return fmt.Sprintf("https://auth.tdameritrade.com/oauth?client_id=%s@AMER.OAUTHAP&response_type=code&redirect_uri=%s", c.ConsumerKey, "https://localhost:8443/auth")
+	return c.AuthURL("")
 }
 
-func getStoredRefreshToken() (string, error) {
-	envToken := os.Getenv("REFRESH_TOKEN")
-	if envToken != "" {
-		return envToken, nil
+// AuthURL is the authorize url, carrying state back to the redirect uri if set
+func (c *Client) AuthURL(state string) string {
+	query := url.Values{
+		"client_id":     []string{c.ConsumerKey + "@AMER.OAUTHAP"},
+		"response_type": []string{"code"},
+		"redirect_uri":  []string{c.redirectURI()},
 	}
-	configDir, err := getConfigDir()
-	if err != nil {
-		return "", err
+	if state != "" {
+		query.Set("state", state)
 	}
-	f, err := os.Open(path.Join(configDir, "tdam_refresh"))
-	if err != nil {
-		return "", err
-	}
-	b, err := ioutil.ReadAll(f)
-	return string(b), err
+	return fmt.Sprintf("%s/oauth?%s", c.authBaseURL(), query.Encode())
 }
 
 func getConfigDir() (string, error) {
@@ -127,66 +152,85 @@ type TokenResponse struct {
 	RefreshTokenExpiresIn int `json:"refresh_token_expires_in"`
 }
 
+func (t *TokenResponse) setExpiries(now time.Time) {
+	t.AccessExpiry = now.Add(time.Duration(t.ExpiresIn) * time.Second)
+	// refresh grants without access_type=offline don't return a refresh token
+	if t.RefreshTokenExpiresIn > 0 {
+		t.RefreshExpiry = now.Add(time.Duration(t.RefreshTokenExpiresIn) * time.Second)
+	}
+}
+
+// carries over the refresh token from prev if t didn't come with one
+func (t *TokenResponse) keepRefreshToken(prev *TokenResponse) {
+	if t.RefreshToken == "" && prev != nil {
+		t.RefreshToken = prev.RefreshToken
+		t.RefreshExpiry = prev.RefreshExpiry
+	}
+}
+
 func (c *Client) AuthHandler(w http.ResponseWriter, req *http.Request) {
 	code := req.URL.Query().Get("code")
 
-	token, err := c.getToken(code)
+	token, err := c.getToken(req.Context(), code)
 	if err != nil {
 		log.Printf("Error getting token: %v\n", err)
+		http.Error(w, fmt.Sprintf("error getting token: %v", err), http.StatusBadGateway)
+		return
 	}
 
 	w.Header().Set("Content-Type", "text/plain")
 	if err := c.SetToken(token); err != nil {
-		fmt.Printf("error setting token: %v\n", err)
+		log.Printf("error setting token: %v\n", err)
 		fmt.Fprintf(w, "error setting token: %v\n", err)
 	} else {
 		fmt.Fprintf(w, "token acquired! have fun:  %v", token)
 	}
 }
 
-func (c *Client) getToken(code string) (*TokenResponse, error) {
-	transport := &http.Transport{TLSClientConfig: &tls.Config{}}
-	client := &http.Client{Transport: transport}
-
+func (c *Client) getToken(ctx context.Context, code string) (*TokenResponse, error) {
 	form := url.Values{
 		"grant_type":   []string{"authorization_code"},
 		"access_type":  []string{"offline"},
 		"code":         []string{code},
 		"client_id":    []string{c.ConsumerKey},
-		"redirect_uri": []string{"https://localhost:8443/auth"},
+		"redirect_uri": []string{c.redirectURI()},
 	}
 
-	req, err := http.NewRequest("POST", tokenEndpoint, bytes.NewBuffer([]byte(form.Encode())))
+	resp, err := c.postTokenForm(ctx, form)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := client.Do(req)
-	if err != nil {
+	defer resp.Body.Close()
+	if err := CheckResponse(resp); err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
 	var token TokenResponse
 	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
-		fmt.Printf("error decoding token response: %v\n", err)
-		return nil, err
+		return nil, fmt.Errorf("decoding token response: %w", err)
 	}
 
-	token.AccessExpiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
-	token.RefreshExpiry = time.Now().Add(time.Duration(token.RefreshTokenExpiresIn) * time.Second)
+	token.setExpiries(time.Now())
 
 	return &token, err
 }
 
-func (c *Client) refreshToken(code string) (*TokenResponse, error) {
+func (c *Client) refreshToken(ctx context.Context, code string) (*TokenResponse, error) {
+	// only set this on an initial request (ie without refresh_token)
+	return c.refreshGrant(ctx, code, code == "")
+}
+
+// rotateRefreshToken exchanges a refresh token for a new refresh token
+// (and access token) before the old one expires
+func (c *Client) rotateRefreshToken(ctx context.Context, code string) (*TokenResponse, error) {
+	return c.refreshGrant(ctx, code, true)
+}
+
+func (c *Client) refreshGrant(ctx context.Context, code string, offline bool) (*TokenResponse, error) {
 	if c == nil {
 		return nil, fmt.Errorf("can't get a token with a nil client!")
 	}
-	transport := &http.Transport{TLSClientConfig: &tls.Config{}}
-	client := &http.Client{Transport: transport}
-
 	form := url.Values{
 		"grant_type": []string{"refresh_token"},
 		// as per https://developer.tdameritrade.com/authentication/apis/post/token-0
@@ -194,159 +238,43 @@ func (c *Client) refreshToken(code string) (*TokenResponse, error) {
 		//	"access_type":   []string{"offline"},
 		"refresh_token": []string{code},
 		"client_id":     []string{c.ConsumerKey},
-		"redirect_uri":  []string{"https://localhost:8443/auth"},
+		"redirect_uri":  []string{c.redirectURI()},
 	}
-	// only set this on an initial request (ie without refresh_token)
-	if code == "" {
+	if offline {
 		form.Set("access_type", "offline")
 	}
 
-	req, err := http.NewRequest("POST", tokenEndpoint, bytes.NewBuffer([]byte(form.Encode())))
+	resp, err := c.postTokenForm(ctx, form)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := client.Do(req)
-	if err != nil {
+	defer resp.Body.Close()
+	if err := CheckResponse(resp); err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
 	var token TokenResponse
 	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
-		fmt.Printf("error decoding token response: %v\n", err)
-		return nil, err
+		return nil, fmt.Errorf("decoding token response: %w", err)
 	}
 
 	if token.Error != "" {
 		return &token, fmt.Errorf("%s", token.Error)
 	}
 
-	token.AccessExpiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
-	token.RefreshExpiry = time.Now().Add(time.Duration(token.RefreshTokenExpiresIn) * time.Second)
+	token.setExpiries(time.Now())
 
 	return &token, err
 }
 
-func (c *Client) slogThroughOauthFlow() error {
-	username := os.Getenv("TDAM_USERNAME")
-	password := os.Getenv("TDAM_PASSWORD")
-	if username == "" || password == "" {
-		return fmt.Errorf("Must set TDAM_USERNAME and TDAM_PASSWORD for automatic oauth\n")
-	}
-	fmt.Printf("hang on, let's try to do this ourselves\n")
-
-	bow := surf.NewBrowser()
-	err := bow.Open(c.TdamAuthURL())
-	if err != nil {
-		return err
-	}
-	fmt.Println(bow.Title())
-
-	title, err := bow.Find("div.title>h1>p").First().Html()
-	if err != nil {
-		return err
-	}
-	fmt.Printf("step 1 title: %#v\n", title)
-	// at this point, title should be "Secure Log-in"
-	// this title is wrapped in a <p> but 2fa pages aren't
-
-	// Log in to the site.
-	fm, err := bow.Form("#authform")
-	if err != nil {
-		return err
-	}
-	fm.Input("su_username", username)
-	fm.Input("su_password", password)
-	if err := fm.Submit(); err != nil {
-		return err
-	}
-	_ = bow.Body() // the below doesn't seem to work unless we read the body.  Is there a better way?
-	//fmt.Println(bow.Body())
-
-	title, err = bow.Find("div.title>h1").First().Html()
-	if err != nil {
-		return err
-	}
-	fmt.Printf("step 2 title: %#v\n", title)
-	// here, we either are at a 2fa page or the final page
-	// 2fa would be "Get Code via Text Message"
-
-	if title == "Get Code via Text Message" {
-		fmt.Printf("lets try to use the security question\n")
-		fm, err := bow.Form("#authform")
+func (c *Client) postTokenForm(ctx context.Context, form url.Values) (*http.Response, error) {
+	return c.send(ctx, "/v1/oauth2/token", func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.tokenURL(), bytes.NewBuffer([]byte(form.Encode())))
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if err := fm.Click("init_secretquestion"); err != nil {
-			return err
-		}
-		_ = bow.Body()
-		//fmt.Println(bow.Body())
-		title, err := bow.Find("div.title>h1").First().Html()
-		if err != nil {
-			return err
-		}
-		fmt.Printf("step 2b title: %#v\n", title)
-		// here, we should have the title "Answer Security Question'"
-		desc, err := bow.Find("div.description>p").Last().Html()
-		if err != nil {
-			return err
-		}
-		fmt.Printf("step 2b desc: %#v\n", desc)
-
-		env := ""
-		if strings.Contains(desc, "What is your paternal grandmother&#39;s first name?") {
-			env = "TDAM_SQ_ANSWER_1"
-		}
-		if strings.Contains(desc, "What is your maternal grandmother&#39;s first name?") {
-			env = "TDAM_SQ_ANSWER_2"
-		}
-		if strings.Contains(desc, "What was the name of the town your grandmother lived in?") {
-			env = "TDAM_SQ_ANSWER_3"
-		}
-		if strings.Contains(desc, "What is your best friend&#39;s first name?") {
-			env = "TDAM_SQ_ANSWER_4"
-		}
-		if env == "" {
-			return fmt.Errorf("Unknown security question: %#v", desc)
-		}
-		sqAnswer := os.Getenv(env)
-		if sqAnswer == "" {
-			return fmt.Errorf("Please provide the answer to the following question in %s: %#v", env, desc)
-		}
-
-		// ideally we should check the security question but i've only gotten one so far
-		fm, err = bow.Form("#authform")
-		if err != nil {
-			return err
-		}
-		fm.Input("su_secretquestion", sqAnswer)
-		if err := fm.Submit(); err != nil {
-			return err
-		}
-		_ = bow.Body()
-		// fmt.Println(bow.Body())
-	}
-
-	// at this point, we should be at "TD Ameritrade Authorization"
-	// which shows us the scope and asks us to accept
-	title, err = bow.Find("div.title>h1").First().Html()
-	if err != nil {
-		return err
-	}
-	fmt.Printf("step 3 title: %#v\n", title)
-
-	// set the transport to ignore https cert validation
-	bow.SetTransport(&http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req, nil
 	})
-
-	if err := fm.Submit(); err != nil {
-		return err
-	}
-	fmt.Println(bow.Body()) // this should come from our callback server
-
-	return nil
 }
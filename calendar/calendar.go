@@ -0,0 +1,370 @@
+// Package calendar knows the NYSE trading calendar without asking the API:
+// holidays, early closes, and the pre-market, regular and post-market
+// sessions, all in America/New_York.
+//
+// Functions taking a day use its date in New York, so build dates with
+// Date rather than time.Date in UTC, which is the evening before in New York.
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	// the zone is embedded so it's right even where the system has no zoneinfo
+	_ "time/tzdata"
+)
+
+// NewYork is America/New_York
+var NewYork = loadNewYork()
+
+func loadNewYork() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		// a fixed offset would be an hour out for most of the year
+		panic(fmt.Sprintf("calendar: loading America/New_York: %v", err))
+	}
+	return loc
+}
+
+// Date is midnight in New York on the given day
+func Date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, NewYork)
+}
+
+// DateOf is midnight in New York on t's date there
+func DateOf(t time.Time) time.Time {
+	y, m, d := t.In(NewYork).Date()
+	return Date(y, m, d)
+}
+
+// session times, as TD's extended hours trading has them
+var (
+	preMarketOpen   = clock{7, 0}
+	regularOpen     = clock{9, 30}
+	regularClose    = clock{16, 0}
+	earlyClose      = clock{13, 0}
+	postMarketClose = clock{20, 0}
+	earlyPostClose  = clock{17, 0}
+)
+
+type clock struct {
+	hour, minute int
+}
+
+func (c clock) on(day time.Time) time.Time {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, c.hour, c.minute, 0, 0, NewYork)
+}
+
+type Session int
+
+const (
+	Closed Session = iota
+	PreMarket
+	Regular
+	PostMarket
+)
+
+func (s Session) String() string {
+	switch s {
+	case PreMarket:
+		return "pre-market"
+	case Regular:
+		return "regular"
+	case PostMarket:
+		return "post-market"
+	default:
+		return "closed"
+	}
+}
+
+// Interval is a session's start and end, End excluded
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+func (i Interval) Contains(t time.Time) bool {
+	return !t.Before(i.Start) && t.Before(i.End)
+}
+
+// Day is a trading day's sessions
+type Day struct {
+	Date       time.Time
+	EarlyClose bool
+	PreMarket  Interval
+	Regular    Interval
+	PostMarket Interval
+}
+
+// TradingDay returns the sessions on t's date, false if the market is closed that day
+func TradingDay(t time.Time) (Day, bool) {
+	date := DateOf(t)
+	if !IsTradingDay(date) {
+		return Day{Date: date}, false
+	}
+	closeAt, postClose := regularClose, postMarketClose
+	early := IsEarlyClose(date)
+	if early {
+		closeAt, postClose = earlyClose, earlyPostClose
+	}
+	return Day{
+		Date:       date,
+		EarlyClose: early,
+		PreMarket:  Interval{preMarketOpen.on(date), regularOpen.on(date)},
+		Regular:    Interval{regularOpen.on(date), closeAt.on(date)},
+		PostMarket: Interval{closeAt.on(date), postClose.on(date)},
+	}, true
+}
+
+// SessionAt is the session in progress at t
+func SessionAt(t time.Time) Session {
+	day, ok := TradingDay(t)
+	switch {
+	case !ok:
+		return Closed
+	case day.PreMarket.Contains(t):
+		return PreMarket
+	case day.Regular.Contains(t):
+		return Regular
+	case day.PostMarket.Contains(t):
+		return PostMarket
+	}
+	return Closed
+}
+
+// IsOpen is true during the regular session
+func IsOpen(t time.Time) bool {
+	return SessionAt(t) == Regular
+}
+
+// NextOpen is the start of the next regular session after t.  If the
+// market is open at t, that's tomorrow's open, or the next trading day's.
+func NextOpen(t time.Time) time.Time {
+	day := DateOf(t)
+	for {
+		if d, ok := TradingDay(day); ok && d.Regular.Start.After(t) {
+			return d.Regular.Start
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+}
+
+// NextClose is the end of the regular session in progress at t, or of
+// the next one
+func NextClose(t time.Time) time.Time {
+	day := DateOf(t)
+	for {
+		if d, ok := TradingDay(day); ok && d.Regular.End.After(t) {
+			return d.Regular.End
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+}
+
+// IsTradingDay is true if the market opens on t's date
+func IsTradingDay(t time.Time) bool {
+	date := DateOf(t)
+	if wd := date.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return false
+	}
+	_, holiday := holidayName(date)
+	return !holiday
+}
+
+// NextTradingDay is the first trading day after t's date
+func NextTradingDay(t time.Time) time.Time {
+	day := DateOf(t).AddDate(0, 0, 1)
+	for !IsTradingDay(day) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// PreviousTradingDay is the last trading day before t's date
+func PreviousTradingDay(t time.Time) time.Time {
+	day := DateOf(t).AddDate(0, 0, -1)
+	for !IsTradingDay(day) {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+// TradingDays counts the trading days after from's date, up to and
+// including to's date.  It's negative if to is before from.
+func TradingDays(from, to time.Time) int {
+	from, to = DateOf(from), DateOf(to)
+	sign := 1
+	if to.Before(from) {
+		from, to, sign = to, from, -1
+	}
+	n := 0
+	for day := from.AddDate(0, 0, 1); !day.After(to); day = day.AddDate(0, 0, 1) {
+		if IsTradingDay(day) {
+			n++
+		}
+	}
+	return sign * n
+}
+
+// DTE is the number of trading days from now until expiration, counting
+// expiration day but not today, so an option expiring today has 0
+func DTE(now, expiration time.Time) int {
+	return TradingDays(now, expiration)
+}
+
+// Holiday is a day the market is closed, other than a weekend
+type Holiday struct {
+	Date time.Time
+	Name string
+}
+
+// Holidays lists the year's market holidays in date order
+func Holidays(year int) []Holiday {
+	var out []Holiday
+	for _, h := range yearHolidays(year) {
+		if wd := h.Date.Weekday(); wd != time.Saturday && wd != time.Sunday && h.Date.Year() == year {
+			out = append(out, h)
+		}
+	}
+	for date, name := range specialClosures {
+		if date.year == year {
+			out = append(out, Holiday{date.on(), name})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Date.Before(out[j].Date) })
+	return out
+}
+
+// IsHoliday is true if t's date is a market holiday
+func IsHoliday(t time.Time) bool {
+	_, ok := holidayName(DateOf(t))
+	return ok
+}
+
+func holidayName(date time.Time) (string, bool) {
+	if name, ok := specialClosures[ymd{date.Year(), date.Month(), date.Day()}]; ok {
+		return name, true
+	}
+	for _, h := range yearHolidays(date.Year()) {
+		if h.Date.Equal(date) {
+			return h.Name, true
+		}
+	}
+	return "", false
+}
+
+// IsEarlyClose is true if the regular session closes at 1pm on t's date:
+// the day before Independence Day, the day after Thanksgiving and
+// Christmas Eve, when they're trading days
+func IsEarlyClose(t time.Time) bool {
+	date := DateOf(t)
+	if !IsTradingDay(date) {
+		return false
+	}
+	y, m, d := date.Date()
+	switch {
+	case m == time.July && d == 3:
+		return true
+	case m == time.December && d == 24:
+		return true
+	case m == time.November:
+		return date.Equal(nthWeekday(y, time.November, time.Thursday, 4).AddDate(0, 0, 1))
+	}
+	return false
+}
+
+type ymd struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+func (d ymd) on() time.Time {
+	return Date(d.year, d.month, d.day)
+}
+
+// closures that weren't regular holidays
+var specialClosures = map[ymd]string{
+	{2001, time.September, 11}: "September 11",
+	{2001, time.September, 12}: "September 11",
+	{2001, time.September, 13}: "September 11",
+	{2001, time.September, 14}: "September 11",
+	{2004, time.June, 11}:      "Reagan National Day of Mourning",
+	{2007, time.January, 2}:    "Ford National Day of Mourning",
+	{2012, time.October, 29}:   "Hurricane Sandy",
+	{2012, time.October, 30}:   "Hurricane Sandy",
+	{2018, time.December, 5}:   "Bush National Day of Mourning",
+	{2025, time.January, 9}:    "Carter National Day of Mourning",
+}
+
+// the year's rule based holidays on their observed dates, which may fall
+// on a weekend or in another year and then aren't observed at all
+func yearHolidays(year int) []Holiday {
+	holidays := []Holiday{
+		{newYears(year), "New Year's Day"},
+		{nthWeekday(year, time.February, time.Monday, 3), "Washington's Birthday"},
+		{easter(year).AddDate(0, 0, -2), "Good Friday"},
+		{lastWeekday(year, time.May, time.Monday), "Memorial Day"},
+		{observed(Date(year, time.July, 4)), "Independence Day"},
+		{nthWeekday(year, time.September, time.Monday, 1), "Labor Day"},
+		{nthWeekday(year, time.November, time.Thursday, 4), "Thanksgiving Day"},
+		{observed(Date(year, time.December, 25)), "Christmas Day"},
+	}
+	if year >= 1998 {
+		holidays = append(holidays, Holiday{nthWeekday(year, time.January, time.Monday, 3), "Martin Luther King, Jr. Day"})
+	}
+	if year >= 2022 {
+		holidays = append(holidays, Holiday{observed(Date(year, time.June, 19)), "Juneteenth"})
+	}
+	return holidays
+}
+
+// a Saturday holiday is observed the Friday before, a Sunday one the Monday after
+func observed(date time.Time) time.Time {
+	switch date.Weekday() {
+	case time.Saturday:
+		return date.AddDate(0, 0, -1)
+	case time.Sunday:
+		return date.AddDate(0, 0, 1)
+	}
+	return date
+}
+
+// the NYSE doesn't close the Friday before when New Year's Day is a Saturday
+func newYears(year int) time.Time {
+	date := Date(year, time.January, 1)
+	if date.Weekday() == time.Sunday {
+		return date.AddDate(0, 0, 1)
+	}
+	return date
+}
+
+func nthWeekday(year int, month time.Month, wd time.Weekday, n int) time.Time {
+	date := Date(year, month, 1)
+	offset := (int(wd) - int(date.Weekday()) + 7) % 7
+	return date.AddDate(0, 0, offset+7*(n-1))
+}
+
+func lastWeekday(year int, month time.Month, wd time.Weekday) time.Time {
+	date := Date(year, month+1, 0)
+	offset := (int(date.Weekday()) - int(wd) + 7) % 7
+	return date.AddDate(0, 0, -offset)
+}
+
+// Easter Sunday, by the anonymous Gregorian algorithm
+func easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return Date(year, time.Month(month), day)
+}
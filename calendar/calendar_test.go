@@ -0,0 +1,119 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func dates(holidays []Holiday) []string {
+	var out []string
+	for _, h := range holidays {
+		out = append(out, h.Date.Format("01-02"))
+	}
+	return out
+}
+
+func TestHolidays(t *testing.T) {
+	for year, want := range map[int][]string{
+		2021: {"01-01", "01-18", "02-15", "04-02", "05-31", "07-05", "09-06", "11-25", "12-24"},
+		2022: {"01-17", "02-21", "04-15", "05-30", "06-20", "07-04", "09-05", "11-24", "12-26"},
+		2023: {"01-02", "01-16", "02-20", "04-07", "05-29", "06-19", "07-04", "09-04", "11-23", "12-25"},
+		2025: {"01-01", "01-09", "01-20", "02-17", "04-18", "05-26", "06-19", "07-04", "09-01", "11-27", "12-25"},
+	} {
+		got := dates(Holidays(year))
+		if len(got) != len(want) {
+			t.Errorf("%d: got %v, want %v", year, got, want)
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%d: got %v, want %v", year, got, want)
+				break
+			}
+		}
+	}
+}
+
+func TestEarlyClose(t *testing.T) {
+	for _, tc := range []struct {
+		date  time.Time
+		early bool
+	}{
+		{Date(2023, time.July, 3), true},
+		{Date(2023, time.November, 24), true},
+		{Date(2021, time.November, 26), true},
+		{Date(2021, time.December, 24), false}, // a holiday that year
+		{Date(2020, time.December, 24), true},
+		{Date(2020, time.July, 3), false},
+		{Date(2023, time.November, 22), false},
+	} {
+		if got := IsEarlyClose(tc.date); got != tc.early {
+			t.Errorf("%s: early close %v, want %v", tc.date.Format("2006-01-02"), got, tc.early)
+		}
+	}
+
+	day, ok := TradingDay(Date(2023, time.November, 24))
+	if !ok || day.Regular.End.Hour() != 13 || day.PostMarket.End.Hour() != 17 {
+		t.Errorf("unexpected early close day %+v", day)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	at := func(day time.Time, hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	fri := Date(2021, time.March, 12)
+	for _, tc := range []struct {
+		t    time.Time
+		want Session
+	}{
+		{at(fri, 6, 59), Closed},
+		{at(fri, 7, 0), PreMarket},
+		{at(fri, 9, 30), Regular},
+		{at(fri, 15, 59), Regular},
+		{at(fri, 16, 0), PostMarket},
+		{at(fri, 20, 0), Closed},
+		{at(fri.AddDate(0, 0, 1), 12, 0), Closed},
+		{time.Date(2021, time.March, 12, 15, 0, 0, 0, time.UTC), Regular}, // 10am in New York
+	} {
+		if got := SessionAt(tc.t); got != tc.want {
+			t.Errorf("%v: got %v, want %v", tc.t, got, tc.want)
+		}
+	}
+	if !IsOpen(at(fri, 10, 0)) || IsOpen(at(fri, 17, 0)) {
+		t.Errorf("IsOpen wrong")
+	}
+
+	// thursday before Good Friday opens again on monday
+	thu := Date(2021, time.April, 1)
+	if got, want := NextOpen(at(thu, 12, 0)), at(Date(2021, time.April, 5), 9, 30); !got.Equal(want) {
+		t.Errorf("next open %v, want %v", got, want)
+	}
+	if got, want := NextOpen(at(thu, 8, 0)), at(thu, 9, 30); !got.Equal(want) {
+		t.Errorf("next open %v, want %v", got, want)
+	}
+	if got, want := NextClose(at(thu, 12, 0)), at(thu, 16, 0); !got.Equal(want) {
+		t.Errorf("next close %v, want %v", got, want)
+	}
+}
+
+func TestDTE(t *testing.T) {
+	// across Good Friday and a weekend
+	now := Date(2021, time.March, 31).Add(10 * time.Hour)
+	exp := Date(2021, time.April, 9)
+	if got := DTE(now, exp); got != 6 {
+		t.Errorf("dte %d, want 6", got)
+	}
+	if got := DTE(exp, exp); got != 0 {
+		t.Errorf("expiring today should be 0, got %d", got)
+	}
+	if got := TradingDays(exp, now); got != -6 {
+		t.Errorf("backwards %d", got)
+	}
+	if got := NextTradingDay(Date(2021, time.April, 1)); !got.Equal(Date(2021, time.April, 5)) {
+		t.Errorf("next trading day %v", got)
+	}
+	if got := PreviousTradingDay(Date(2021, time.April, 5)); !got.Equal(Date(2021, time.April, 1)) {
+		t.Errorf("previous trading day %v", got)
+	}
+}
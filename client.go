@@ -1,9 +1,204 @@
 package tdam
 
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultAPIBaseURL  = "https://api.tdameritrade.com"
+	DefaultAuthBaseURL = "https://auth.tdameritrade.com"
+	DefaultTokenURL    = DefaultAPIBaseURL + "/v1/oauth2/token"
+	DefaultRedirectURI = "https://localhost:8443/auth"
+)
+
 type Client struct {
 	ConsumerKey string
+
+	// base urls may be pointed at a local stand-in server for testing
+	APIBaseURL  string
+	AuthBaseURL string
+	TokenURL    string
+
+	// must match the callback url registered for the consumer key
+	RedirectURI string
+
+	// shared by every request made through this client.
+	// if nil, http.DefaultClient is used
+	HTTPClient *http.Client
+
+	// if nil, requests are not throttled client-side
+	RateLimiter *RateLimiter
+
+	// 429s, and 5xx on GETs, are retried up to MaxRetries times,
+	// waiting Retry-After or RetryBackoff doubled per attempt
+	MaxRetries   int
+	RetryBackoff time.Duration
+
+	// where access and refresh tokens are persisted.  If nil, REFRESH_TOKEN
+	// from the environment is used if set, otherwise ~/.tdam/tdam_refresh
+	TokenStore TokenStore
+
+	// answers second factor challenges in the automatic login flow.
+	// If nil, security questions are answered from TDAM_SQ_ANSWER_1..4
+	SecondFactor SecondFactorProvider
+
+	authMu  sync.Mutex // held while obtaining a token
+	tokenMu sync.Mutex // guards token
+	token   *TokenResponse
+
+	// return ErrNoToken rather than attempting the automatic login flow
+	noLoginFlow bool
 }
 
 func NewClient(consumerKey string) *Client {
-	return &Client{consumerKey}
+	return &Client{
+		ConsumerKey: consumerKey,
+		APIBaseURL:  DefaultAPIBaseURL,
+		AuthBaseURL: DefaultAuthBaseURL,
+		TokenURL:    DefaultTokenURL,
+		RedirectURI: DefaultRedirectURI,
+		HTTPClient:  &http.Client{},
+
+		RateLimiter:  NewRateLimiter(DefaultRequestsPerMinute),
+		MaxRetries:   3,
+		RetryBackoff: 500 * time.Millisecond,
+	}
+}
+
+// Request describes a single call against the REST API.
+// Endpoint is relative to APIBaseURL, eg "/v1/accounts".
+// Unauthenticated requests are sent with the consumer key as apikey.
+type Request struct {
+	Method        string
+	Endpoint      string
+	Query         url.Values
+	Body          interface{} // encoded as json if not nil
+	Authenticated bool
+}
+
+func (c *Client) apiBaseURL() string {
+	if c.APIBaseURL == "" {
+		return DefaultAPIBaseURL
+	}
+	return strings.TrimSuffix(c.APIBaseURL, "/")
+}
+
+func (c *Client) authBaseURL() string {
+	if c.AuthBaseURL == "" {
+		return DefaultAuthBaseURL
+	}
+	return strings.TrimSuffix(c.AuthBaseURL, "/")
+}
+
+func (c *Client) tokenURL() string {
+	if c.TokenURL == "" {
+		return c.apiBaseURL() + "/v1/oauth2/token"
+	}
+	return c.TokenURL
+}
+
+func (c *Client) redirectURI() string {
+	if c.RedirectURI == "" {
+		return DefaultRedirectURI
+	}
+	return c.RedirectURI
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// Do sends r and returns the raw response.  The caller must close the body.
+func (c *Client) Do(r Request) (*http.Response, error) {
+	return c.DoContext(context.Background(), r)
+}
+
+// DoContext is Do with a context that bounds the whole request,
+// including any token refresh it triggers.
+func (c *Client) DoContext(ctx context.Context, r Request) (*http.Response, error) {
+	if c == nil {
+		return nil, fmt.Errorf("can't make a request with a nil client!")
+	}
+
+	method := r.Method
+	if method == "" {
+		method = "GET"
+	}
+
+	var body []byte
+	if r.Body != nil {
+		b, err := json.Marshal(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+
+	query := url.Values{}
+	for k, v := range r.Query {
+		query[k] = append([]string(nil), v...)
+	}
+	var auth string
+	if r.Authenticated {
+		token, err := c.TDAMTokenContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		auth = fmt.Sprintf("Bearer %s", token)
+	} else {
+		query.Set("apikey", c.ConsumerKey)
+	}
+
+	return c.send(ctx, r.Endpoint, func() (*http.Request, error) {
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, c.apiBaseURL()+r.Endpoint, reader)
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		req.URL.RawQuery = query.Encode()
+		return req, nil
+	})
+}
+
+// DoJSON sends r and decodes the response body into out.
+func (c *Client) DoJSON(r Request, out interface{}) error {
+	return c.DoJSONContext(context.Background(), r, out)
+}
+
+func (c *Client) DoJSONContext(ctx context.Context, r Request, out interface{}) error {
+	resp, err := c.DoContext(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := CheckResponse(resp); err != nil {
+		return err
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
 }
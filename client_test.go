@@ -0,0 +1,112 @@
+package tdam
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// counts requests sent through a client's HTTPClient
+type countingTransport struct {
+	n int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.n++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClientURLs(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		paths = append(paths, req.URL.Path)
+		switch req.URL.Path {
+		case "/oauth2/token":
+			json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "fresh", "expires_in": 1800})
+		case "/api/v1/accounts":
+			if req.Header.Get("Authorization") != "Bearer fresh" {
+				t.Errorf("unexpected authorization %q", req.Header.Get("Authorization"))
+			}
+			w.Write([]byte(`[]`))
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer srv.Close()
+
+	transport := &countingTransport{}
+	c := NewClient("KEY")
+	c.APIBaseURL = srv.URL + "/api/"
+	c.AuthBaseURL = "https://auth.example.com/"
+	c.TokenURL = srv.URL + "/oauth2/token"
+	c.HTTPClient = &http.Client{Transport: transport}
+	c.RateLimiter = nil
+	c.TokenStore = NewMemoryTokenStore()
+	c.TokenStore.SaveToken(&TokenResponse{RefreshToken: "refresh", RefreshExpiry: time.Now().Add(time.Hour)})
+
+	var out []interface{}
+	if err := c.DoJSON(Request{Endpoint: "/v1/accounts", Authenticated: true}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(paths, " ") != "/oauth2/token /api/v1/accounts" {
+		t.Errorf("unexpected requests %v", paths)
+	}
+	if transport.n != 2 {
+		t.Errorf("expected both requests through HTTPClient, got %d", transport.n)
+	}
+	if u := c.AuthURL(""); !strings.HasPrefix(u, "https://auth.example.com/oauth?") {
+		t.Errorf("unexpected auth url %s", u)
+	}
+
+	// zero values fall back to the defaults
+	var zero Client
+	if zero.apiBaseURL() != DefaultAPIBaseURL || zero.authBaseURL() != DefaultAuthBaseURL ||
+		zero.tokenURL() != DefaultTokenURL || zero.httpClient() != http.DefaultClient {
+		t.Errorf("unexpected defaults for a zero client")
+	}
+}
+
+func TestContextCancels(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c := NewClient("KEY")
+	c.APIBaseURL = srv.URL
+	c.TokenURL = srv.URL + "/v1/oauth2/token"
+	c.RateLimiter = nil
+	c.TokenStore = NewMemoryTokenStore()
+	c.TokenStore.SaveToken(&TokenResponse{RefreshToken: "refresh"})
+
+	for name, call := range map[string]func(ctx context.Context) error{
+		"request": func(ctx context.Context) error {
+			return c.DoJSONContext(ctx, Request{Endpoint: "/v1/marketdata/quotes"}, nil)
+		},
+		"token refresh": func(ctx context.Context) error {
+			_, err := c.TDAMTokenContext(ctx)
+			return err
+		},
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		start := time.Now()
+		err := call(ctx)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: expected the deadline to cancel it, got %v", name, err)
+		}
+		if d := time.Since(start); d > 2*time.Second {
+			t.Errorf("%s: took %v to give up", name, d)
+		}
+	}
+}
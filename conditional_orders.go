@@ -0,0 +1,147 @@
+package tdam
+
+import "fmt"
+
+// OCO combines orders so that when one fills the others are canceled
+func OCO(orders ...Order) Order {
+	return Order{
+		OrderStrategyType:    StrategyOCO,
+		ChildOrderStrategies: orders,
+	}
+}
+
+// Trigger places the then orders once first has filled
+func Trigger(first Order, then ...Order) Order {
+	first.OrderStrategyType = StrategyTrigger
+	first.ChildOrderStrategies = append(append([]Order(nil), first.ChildOrderStrategies...), then...)
+	return first
+}
+
+// Bracket places entry, and once it fills, target and stop as an OCO so
+// whichever exit fills first cancels the other
+func Bracket(entry, target, stop Order) Order {
+	return Trigger(entry, OCO(target, stop))
+}
+
+var closingInstructions = map[Instruction]Instruction{
+	InstructionBuy:        InstructionSell,
+	InstructionSellShort:  InstructionBuyToCover,
+	InstructionBuyToOpen:  InstructionSellToClose,
+	InstructionSellToOpen: InstructionBuyToClose,
+}
+
+// Closing starts an order closing the position entry opens: the same legs
+// and quantities with the opposite instructions.  The price and duration
+// are up to the caller.
+func Closing(entry Order) *OrderBuilder {
+	b := &OrderBuilder{}
+	for _, leg := range entry.OrderLegCollection {
+		if leg.Instrument == nil {
+			b.errorf("leg %d has no instrument", leg.LegId)
+			continue
+		}
+		instruction, ok := closingInstructions[leg.Instruction]
+		if !ok {
+			b.errorf("%s doesn't open a position to close", leg.Instruction)
+			continue
+		}
+		b.instrument = leg.Instrument
+		b.leg(instruction, leg.Quantity)
+	}
+	b.order.Quantity = entry.Quantity
+	return b.Strategy(entry.ComplexOrderStrategyType)
+}
+
+// profits when the price goes up, ie bought rather than sold short or for a credit
+func isLong(entry Order) bool {
+	switch entry.OrderType {
+	case OrderTypeNetDebit:
+		return true
+	case OrderTypeNetCredit:
+		return false
+	}
+	if len(entry.OrderLegCollection) == 0 {
+		return true
+	}
+	i := entry.OrderLegCollection[0].Instruction
+	return i == InstructionBuy || i == InstructionBuyToOpen
+}
+
+// Bracket builds the order and wraps it in a Bracket with a good till
+// cancel profit target and stop loss closing the position:
+//
+//	order, err := tdam.Equity("AAPL").Buy(100).Limit(150).Bracket(165, 140)
+//
+// A stop of 0 leaves out the stop loss, so the target is simply triggered
+// by the entry.  TD doesn't take stop orders on spreads, so a spread's
+// stop is a closing net order priced at the stop instead: a credit spread
+// opened for 1.05 might have a target of 0.50 and a stop of 2.10, the net
+// prices to buy it back.
+func (b *OrderBuilder) Bracket(target, stop float64) (Order, error) {
+	entry, err := b.Build()
+	if err != nil {
+		return entry, err
+	}
+
+	var errs []string
+	if stop != 0 {
+		if long := isLong(entry); long && target <= stop {
+			errs = append(errs, fmt.Sprintf("profit target %g must be above stop %g", target, stop))
+		} else if !long && target >= stop {
+			errs = append(errs, fmt.Sprintf("profit target %g must be below stop %g", target, stop))
+		}
+	}
+
+	targetOrder, err := closingAt(entry, target).Build()
+	errs = append(errs, prefixErrors("profit target", err)...)
+
+	if stop == 0 {
+		if len(errs) > 0 {
+			return entry, &ValidationError{Errors: errs}
+		}
+		return Trigger(entry, targetOrder), nil
+	}
+
+	var stopOrder Order
+	if len(entry.OrderLegCollection) > 1 {
+		stopOrder, err = closingAt(entry, stop).Build()
+	} else {
+		stopOrder, err = Closing(entry).GoodTillCancel().Stop(stop).Build()
+	}
+	errs = append(errs, prefixErrors("stop", err)...)
+
+	if len(errs) > 0 {
+		return entry, &ValidationError{Errors: errs}
+	}
+	return Bracket(entry, targetOrder, stopOrder), nil
+}
+
+// a good till cancel order closing entry at price, net for spreads
+func closingAt(entry Order, price float64) *OrderBuilder {
+	exit := Closing(entry).GoodTillCancel()
+	switch entry.OrderType {
+	case OrderTypeNetCredit:
+		return exit.NetDebit(price)
+	case OrderTypeNetDebit:
+		return exit.NetCredit(price)
+	}
+	return exit.Limit(price)
+}
+
+func prefixErrors(prefix string, err error) []string {
+	var errs []string
+	if v, ok := err.(*ValidationError); ok {
+		for _, e := range v.Errors {
+			errs = append(errs, prefix+": "+e)
+		}
+	} else if err != nil {
+		errs = append(errs, prefix+": "+err.Error())
+	}
+	return errs
+}
+
+// Bracket opens quantity spreads at price with automatic exits, see
+// OrderBuilder.Bracket
+func (s Spread) Bracket(quantity int, price, target, stop float64) (Order, error) {
+	return s.Open(quantity, price).Bracket(target, stop)
+}
@@ -0,0 +1,136 @@
+package tdam
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEquityBracket(t *testing.T) {
+	order, err := Equity("AAPL").Buy(100).Limit(150).Bracket(165, 140)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.OrderStrategyType != StrategyTrigger || len(order.ChildOrderStrategies) != 1 {
+		t.Fatalf("expected a trigger with one child, got %+v", order)
+	}
+	oco := order.ChildOrderStrategies[0]
+	if oco.OrderStrategyType != StrategyOCO || len(oco.ChildOrderStrategies) != 2 {
+		t.Fatalf("expected an OCO with two children, got %+v", oco)
+	}
+	target, stop := oco.ChildOrderStrategies[0], oco.ChildOrderStrategies[1]
+	if target.OrderType != OrderTypeLimit || target.Price != Dollars(165) || target.Duration != DurationGoodTillCancel {
+		t.Errorf("unexpected target %+v", target)
+	}
+	if stop.OrderType != OrderTypeStop || stop.StopPrice != Dollars(140) {
+		t.Errorf("unexpected stop %+v", stop)
+	}
+	for _, exit := range []Order{target, stop} {
+		if leg := exit.OrderLegCollection[0]; leg.Instruction != InstructionSell || leg.Quantity != 100 {
+			t.Errorf("exit should sell 100, got %s %g", leg.Instruction, leg.Quantity)
+		}
+	}
+
+	b, _ := json.Marshal(oco)
+	if !strings.HasPrefix(string(b), `{"orderStrategyType":"OCO","childOrderStrategies":[{`) {
+		t.Errorf("unexpected OCO json %s", b)
+	}
+
+	targetOnly, err := Equity("AAPL").Buy(100).Limit(150).Bracket(165, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targetOnly.ChildOrderStrategies) != 1 || targetOnly.ChildOrderStrategies[0].OrderType != OrderTypeLimit {
+		t.Errorf("expected just a limit target with no stop, got %+v", targetOnly.ChildOrderStrategies)
+	}
+
+	if _, err := Equity("AAPL").SellShort(100).Limit(150).Bracket(165, 140); err == nil {
+		t.Errorf("short bracket with target above stop should fail")
+	}
+}
+
+func TestCreditSpreadBracket(t *testing.T) {
+	spread, _ := Vertical("SPY_121820P290", "SPY_121820P300")
+	order, err := spread.Bracket(1, 1.05, 0.50, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.OrderType != OrderTypeNetCredit {
+		t.Errorf("entry should be a credit, got %s", order.OrderType)
+	}
+	if order.OrderStrategyType != StrategyTrigger || len(order.ChildOrderStrategies) != 1 {
+		t.Fatalf("expected a trigger with just the target, got %+v", order)
+	}
+	target := order.ChildOrderStrategies[0]
+	if target.OrderStrategyType != StrategySingle || target.OrderType != OrderTypeNetDebit ||
+		target.Price != Dollars(0.50) || target.Duration != DurationGoodTillCancel {
+		t.Errorf("unexpected target %+v", target)
+	}
+	for i, want := range []Instruction{InstructionSellToClose, InstructionBuyToClose} {
+		if leg := target.OrderLegCollection[i]; leg.Instruction != want || leg.Quantity != 1 {
+			t.Errorf("target leg %d: %s %g, want %s 1", i, leg.Instruction, leg.Quantity, want)
+		}
+	}
+	if err := order.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	order, err = spread.Bracket(1, 1.05, 0.50, 2.10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oco := order.ChildOrderStrategies[0]
+	if oco.OrderStrategyType != StrategyOCO || len(oco.ChildOrderStrategies) != 2 {
+		t.Fatalf("expected an OCO of target and stop, got %+v", oco)
+	}
+	stop := oco.ChildOrderStrategies[1]
+	if stop.OrderType != OrderTypeNetDebit || stop.Price != Dollars(2.10) || stop.StopPrice != 0 ||
+		stop.Duration != DurationGoodTillCancel || stop.ComplexOrderStrategyType != order.ComplexOrderStrategyType {
+		t.Errorf("unexpected stop %+v", stop)
+	}
+	for i, want := range []Instruction{InstructionSellToClose, InstructionBuyToClose} {
+		if leg := stop.OrderLegCollection[i]; leg.Instruction != want || leg.Quantity != 1 {
+			t.Errorf("stop leg %d: %s %g, want %s 1", i, leg.Instruction, leg.Quantity, want)
+		}
+	}
+	if err := order.Validate(); err != nil {
+		t.Error(err)
+	}
+	if _, err := spread.Bracket(1, 1.05, 2.10, 0.50); err == nil {
+		t.Errorf("credit spread bracket with target above stop should fail")
+	}
+
+	// a hand built spread stop doesn't get past Validate either
+	entry, _ := spread.Open(1, 1.05).Build()
+	if _, err := Closing(entry).Stop(2.10).Build(); err == nil || !strings.Contains(err.Error(), "STOP orders can only have one leg") {
+		t.Errorf("expected a multi-leg stop to fail validation, got %v", err)
+	}
+}
+
+func TestConditionalValidation(t *testing.T) {
+	single, _ := Equity("AAPL").Buy(1).Limit(1).Build()
+
+	if err := OCO(single).Validate(); err == nil || !strings.Contains(err.Error(), "at least two") {
+		t.Errorf("expected error for one-legged OCO, got %v", err)
+	}
+	bad := single
+	bad.Price = 0
+	if err := OCO(single, bad).Validate(); err == nil || !strings.Contains(err.Error(), "child order 2: LIMIT orders need a price") {
+		t.Errorf("expected child error, got %v", err)
+	}
+	withChild := single
+	withChild.ChildOrderStrategies = []Order{single}
+	if err := withChild.Validate(); err == nil {
+		t.Errorf("expected error for SINGLE with children")
+	}
+	if err := Trigger(single, OCO(single, single)).Validate(); err != nil {
+		t.Error(err)
+	}
+	if _, err := Closing(single).Limit(2).Build(); err != nil {
+		t.Error(err)
+	}
+	sell, _ := Equity("AAPL").Sell(1).Build()
+	if _, err := Closing(sell).Build(); err == nil {
+		t.Errorf("expected error closing a closing order")
+	}
+}
@@ -0,0 +1,133 @@
+package tdam
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoToken means no usable token is stored and the client can't log in by itself
+var ErrNoToken = errors.New("no token available, authorization required")
+
+// TokenRefreshError is returned when the stored refresh token can't be
+// exchanged for an access token, usually because it has expired or been
+// revoked.  Logging in again at AuthURL gets a new one.
+type TokenRefreshError struct {
+	Err     error
+	AuthURL string
+}
+
+func (e *TokenRefreshError) Error() string {
+	return fmt.Sprintf("refreshing token: %v; to obtain a new token, visit %s", e.Err, e.AuthURL)
+}
+
+func (e *TokenRefreshError) Unwrap() error {
+	return e.Err
+}
+
+// APIError is returned for any non-2xx response from the TD Ameritrade API.
+type APIError struct {
+	StatusCode int
+	Method     string
+	Endpoint   string
+	Message    string        // the "error" field of TD's error body, or the raw body
+	RetryAfter time.Duration // from the Retry-After header, if present
+	RequestID  string
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s: status %d: %s", e.Method, e.Endpoint, e.StatusCode, msg)
+}
+
+// CheckResponse returns an *APIError if resp has a non-2xx status.
+// The body is consumed in that case, but not closed.
+func CheckResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+	if req := resp.Request; req != nil {
+		e.Method = req.Method
+		e.Endpoint = req.URL.Path
+	}
+	for _, h := range []string{"X-Request-Id", "X-Correlation-Id", "Request-Id"} {
+		if id := resp.Header.Get(h); id != "" {
+			e.RequestID = id
+			break
+		}
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	var tdErr struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &tdErr); err == nil && tdErr.Error != "" {
+		e.Message = tdErr.Error
+	} else {
+		e.Message = strings.TrimSpace(string(body))
+	}
+
+	return e
+}
+
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func statusOf(err error) int {
+	var e *APIError
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+	return 0
+}
+
+// IsUnauthorized is true for a 401, usually an expired or revoked token
+func IsUnauthorized(err error) bool {
+	return statusOf(err) == http.StatusUnauthorized
+}
+
+// IsForbidden is true for a 403, eg an account the token can't see
+func IsForbidden(err error) bool {
+	return statusOf(err) == http.StatusForbidden
+}
+
+// IsNotFound is true for a 404, eg an unknown order id
+func IsNotFound(err error) bool {
+	return statusOf(err) == http.StatusNotFound
+}
+
+// IsRateLimited is true for a 429 from the API, or ErrRateLimited
+// from a fail-fast client-side RateLimiter
+func IsRateLimited(err error) bool {
+	return statusOf(err) == http.StatusTooManyRequests || errors.Is(err, ErrRateLimited)
+}
+
+// IsServerError is true for any 5xx from the API
+func IsServerError(err error) bool {
+	return statusOf(err) >= 500
+}
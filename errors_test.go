@@ -0,0 +1,157 @@
+package tdam
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func errorResponse(status int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    &http.Request{Method: "GET", URL: &url.URL{Path: "/v1/accounts/123/orders"}},
+	}
+}
+
+func TestCheckResponseStatus(t *testing.T) {
+	for _, tc := range []struct {
+		status                                                  int
+		unauthorized, forbidden, notFound, limited, serverError bool
+	}{
+		{status: 200},
+		{status: 201},
+		{status: 400},
+		{status: 401, unauthorized: true},
+		{status: 403, forbidden: true},
+		{status: 404, notFound: true},
+		{status: 429, limited: true},
+		{status: 500, serverError: true},
+		{status: 503, serverError: true},
+	} {
+		err := CheckResponse(errorResponse(tc.status, nil, ""))
+		if (err == nil) != (tc.status < 300) {
+			t.Errorf("%d: unexpected error %v", tc.status, err)
+			continue
+		}
+		if got := IsUnauthorized(err); got != tc.unauthorized {
+			t.Errorf("%d: IsUnauthorized %v", tc.status, got)
+		}
+		if got := IsForbidden(err); got != tc.forbidden {
+			t.Errorf("%d: IsForbidden %v", tc.status, got)
+		}
+		if got := IsNotFound(err); got != tc.notFound {
+			t.Errorf("%d: IsNotFound %v", tc.status, got)
+		}
+		if got := IsRateLimited(err); got != tc.limited {
+			t.Errorf("%d: IsRateLimited %v", tc.status, got)
+		}
+		if got := IsServerError(err); got != tc.serverError {
+			t.Errorf("%d: IsServerError %v", tc.status, got)
+		}
+	}
+
+	// helpers see through wrapping
+	err := fmt.Errorf("placing order: %w", CheckResponse(errorResponse(404, nil, "")))
+	if !IsNotFound(err) {
+		t.Errorf("expected a wrapped 404 to be not found")
+	}
+	if IsNotFound(fmt.Errorf("something else")) || IsRateLimited(nil) {
+		t.Errorf("expected non API errors to match nothing")
+	}
+	if !IsRateLimited(ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited to be rate limited")
+	}
+}
+
+func TestCheckResponseBody(t *testing.T) {
+	for _, tc := range []struct {
+		body, message, text string
+	}{
+		{`{"error":"Order price is too far outside the market"}`, "Order price is too far outside the market",
+			"GET /v1/accounts/123/orders: status 400: Order price is too far outside the market"},
+		{"  not json\n", "not json", "GET /v1/accounts/123/orders: status 400: not json"},
+		{`{"message":"no error field"}`, `{"message":"no error field"}`,
+			`GET /v1/accounts/123/orders: status 400: {"message":"no error field"}`},
+		{"", "", "GET /v1/accounts/123/orders: status 400: Bad Request"},
+	} {
+		header := http.Header{}
+		header.Set("X-Request-Id", "abc123")
+		err := CheckResponse(errorResponse(400, header, tc.body))
+		e, ok := err.(*APIError)
+		if !ok {
+			t.Fatalf("expected *APIError, got %T", err)
+		}
+		if e.Message != tc.message {
+			t.Errorf("body %q: message %q, want %q", tc.body, e.Message, tc.message)
+		}
+		if e.Error() != tc.text {
+			t.Errorf("body %q: error %q, want %q", tc.body, e.Error(), tc.text)
+		}
+		if e.Method != "GET" || e.Endpoint != "/v1/accounts/123/orders" || e.RequestID != "abc123" {
+			t.Errorf("unexpected request details %+v", e)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	for _, tc := range []struct {
+		header   string
+		min, max time.Duration
+	}{
+		{"", 0, 0},
+		{"30", 30 * time.Second, 30 * time.Second},
+		{"0", 0, 0},
+		{"soon", 0, 0},
+		{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 55 * time.Second, time.Minute},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+	} {
+		if d := parseRetryAfter(tc.header); d < tc.min || d > tc.max {
+			t.Errorf("%q: %v, want between %v and %v", tc.header, d, tc.min, tc.max)
+		}
+	}
+
+	header := http.Header{}
+	header.Set("Retry-After", "5")
+	err := CheckResponse(errorResponse(429, header, ""))
+	if e := err.(*APIError); e.RetryAfter != 5*time.Second {
+		t.Errorf("RetryAfter %v, want 5s", e.RetryAfter)
+	}
+}
+
+func TestTokenRefreshError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient("KEY")
+	c.TokenURL = srv.URL
+	c.RateLimiter = nil
+	c.TokenStore = NewMemoryTokenStore()
+	c.TokenStore.SaveToken(&TokenResponse{RefreshToken: "revoked"})
+
+	_, err := c.TDAMToken()
+	var refreshErr *TokenRefreshError
+	if !errors.As(err, &refreshErr) {
+		t.Fatalf("expected a *TokenRefreshError, got %v", err)
+	}
+	if refreshErr.AuthURL != c.TdamAuthURL() || !strings.Contains(err.Error(), c.TdamAuthURL()) {
+		t.Errorf("expected the auth url in %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "invalid_grant" {
+		t.Errorf("expected the API error underneath, got %v", err)
+	}
+}
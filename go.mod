@@ -1,10 +1,8 @@
 module github.com/ianmcmahon/tdam
 
-go 1.13
+go 1.15
 
 require (
-	github.com/PuerkitoBio/goquery v1.6.0 // indirect
+	github.com/PuerkitoBio/goquery v1.6.0
 	github.com/gorilla/websocket v1.4.1
-	github.com/headzoo/surf v1.0.0
-	gopkg.in/headzoo/surf.v1 v1.0.0
 )
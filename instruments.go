@@ -0,0 +1,164 @@
+package tdam
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ianmcmahon/tdam/calendar"
+)
+
+// Projection is how SearchInstruments matches its query
+type Projection string
+
+const (
+	ProjectionSymbolSearch Projection = "symbol-search" // the exact symbol
+	ProjectionSymbolRegex  Projection = "symbol-regex"  // symbols matching a regex, eg "XY.*"
+	ProjectionDescSearch   Projection = "desc-search"   // descriptions containing a keyword
+	ProjectionDescRegex    Projection = "desc-regex"    // descriptions matching a regex
+	ProjectionFundamental  Projection = "fundamental"   // the exact symbol, with Fundamental set
+)
+
+func (p Projection) valid() bool {
+	switch p {
+	case ProjectionSymbolSearch, ProjectionSymbolRegex, ProjectionDescSearch, ProjectionDescRegex, ProjectionFundamental:
+		return true
+	}
+	return false
+}
+
+// MarketInstrument is an instrument as the instruments endpoint describes
+// it.  Fundamental is only set by a fundamental search, BondPrice only for
+// bonds.
+type MarketInstrument struct {
+	InstrumentInfo
+	Exchange    string       `json:"exchange"`
+	BondPrice   float64      `json:"bondPrice,omitempty"`
+	Fundamental *Fundamental `json:"fundamental,omitempty"`
+}
+
+// Fundamental is a security's fundamental data.  Ratios and margins are
+// percentages, MarketCap and MarketCapFloat are in millions.
+type Fundamental struct {
+	Symbol              string  `json:"symbol"`
+	High52              Money   `json:"high52"`
+	Low52               Money   `json:"low52"`
+	DividendAmount      Money   `json:"dividendAmount"` // annual
+	DividendYield       float64 `json:"dividendYield"`
+	DividendDate        string  `json:"dividendDate"` // ex-dividend, see DividendTime
+	PeRatio             float64 `json:"peRatio"`
+	PegRatio            float64 `json:"pegRatio"`
+	PbRatio             float64 `json:"pbRatio"`
+	PrRatio             float64 `json:"prRatio"`
+	PcfRatio            float64 `json:"pcfRatio"`
+	GrossMarginTTM      float64 `json:"grossMarginTTM"`
+	GrossMarginMRQ      float64 `json:"grossMarginMRQ"`
+	NetProfitMarginTTM  float64 `json:"netProfitMarginTTM"`
+	NetProfitMarginMRQ  float64 `json:"netProfitMarginMRQ"`
+	OperatingMarginTTM  float64 `json:"operatingMarginTTM"`
+	OperatingMarginMRQ  float64 `json:"operatingMarginMRQ"`
+	ReturnOnEquity      float64 `json:"returnOnEquity"`
+	ReturnOnAssets      float64 `json:"returnOnAssets"`
+	ReturnOnInvestment  float64 `json:"returnOnInvestment"`
+	QuickRatio          float64 `json:"quickRatio"`
+	CurrentRatio        float64 `json:"currentRatio"`
+	InterestCoverage    float64 `json:"interestCoverage"`
+	TotalDebtToCapital  float64 `json:"totalDebtToCapital"`
+	LtDebtToEquity      float64 `json:"ltDebtToEquity"`
+	TotalDebtToEquity   float64 `json:"totalDebtToEquity"`
+	EpsTTM              float64 `json:"epsTTM"`
+	EpsChangePercentTTM float64 `json:"epsChangePercentTTM"`
+	EpsChangeYear       float64 `json:"epsChangeYear"`
+	EpsChange           float64 `json:"epsChange"`
+	RevChangeYear       float64 `json:"revChangeYear"`
+	RevChangeTTM        float64 `json:"revChangeTTM"`
+	RevChangeIn         float64 `json:"revChangeIn"`
+	SharesOutstanding   float64 `json:"sharesOutstanding"`
+	MarketCapFloat      float64 `json:"marketCapFloat"`
+	MarketCap           float64 `json:"marketCap"`
+	BookValuePerShare   float64 `json:"bookValuePerShare"`
+	ShortIntToFloat     float64 `json:"shortIntToFloat"`
+	ShortIntDayToCover  float64 `json:"shortIntDayToCover"`
+	DivGrowthRate3Year  float64 `json:"divGrowthRate3Year"`
+	DividendPayAmount   Money   `json:"dividendPayAmount"` // per payment
+	DividendPayDate     string  `json:"dividendPayDate"`   // see DividendPayTime
+	Beta                float64 `json:"beta"`
+	Vol1DayAvg          float64 `json:"vol1DayAvg"`
+	Vol10DayAvg         float64 `json:"vol10DayAvg"`
+	Vol3MonthAvg        float64 `json:"vol3MonthAvg"`
+}
+
+// fundamental dates look like "2021-02-05 00:00:00.000", or are blank when
+// there's no dividend
+const fundamentalDateFormat = "2006-01-02 15:04:05.000"
+
+func parseFundamentalDate(s string) time.Time {
+	t, err := time.ParseInLocation(fundamentalDateFormat, strings.TrimSpace(s), calendar.NewYork)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// DividendTime is the ex-dividend date, zero if there isn't one
+func (f *Fundamental) DividendTime() time.Time {
+	return parseFundamentalDate(f.DividendDate)
+}
+
+// DividendPayTime is the dividend's pay date, zero if there isn't one
+func (f *Fundamental) DividendPayTime() time.Time {
+	return parseFundamentalDate(f.DividendPayDate)
+}
+
+// SearchInstruments finds instruments by symbol or description, keyed by
+// symbol.  query is a symbol, a keyword or a regex depending on projection.
+//
+//	found, err := c.SearchInstruments("AAPL", tdam.ProjectionFundamental)
+//	pe := found["AAPL"].Fundamental.PeRatio
+func (c *Client) SearchInstruments(query string, projection Projection) (map[string]*MarketInstrument, error) {
+	return c.SearchInstrumentsContext(context.Background(), query, projection)
+}
+
+func (c *Client) SearchInstrumentsContext(ctx context.Context, query string, projection Projection) (map[string]*MarketInstrument, error) {
+	if query == "" {
+		return nil, fmt.Errorf("instrument search needs a query")
+	}
+	if !projection.valid() {
+		return nil, fmt.Errorf("invalid instrument projection %q", projection)
+	}
+	q := url.Values{}
+	q.Set("symbol", query)
+	q.Set("projection", string(projection))
+
+	instruments := map[string]*MarketInstrument{}
+	if err := c.doMarketDataJSON(ctx, Request{
+		Endpoint: "/v1/instruments",
+		Query:    q,
+	}, &instruments); err != nil {
+		return nil, err
+	}
+	return instruments, nil
+}
+
+// GetInstrumentByCUSIP looks up the instrument with the given CUSIP
+func (c *Client) GetInstrumentByCUSIP(cusip string) (*MarketInstrument, error) {
+	return c.GetInstrumentByCUSIPContext(context.Background(), cusip)
+}
+
+func (c *Client) GetInstrumentByCUSIPContext(ctx context.Context, cusip string) (*MarketInstrument, error) {
+	if cusip == "" {
+		return nil, fmt.Errorf("no cusip given")
+	}
+	var instruments []*MarketInstrument
+	if err := c.doMarketDataJSON(ctx, Request{
+		Endpoint: fmt.Sprintf("/v1/instruments/%s", url.PathEscape(cusip)),
+	}, &instruments); err != nil {
+		return nil, err
+	}
+	if len(instruments) == 0 {
+		return nil, fmt.Errorf("no instrument with cusip %s", cusip)
+	}
+	return instruments[0], nil
+}
@@ -0,0 +1,130 @@
+package tdam_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ianmcmahon/tdam"
+	"github.com/ianmcmahon/tdam/calendar"
+	"github.com/ianmcmahon/tdam/tdamtest"
+)
+
+// a fake with AAPL and its fundamentals from testdata, plus a bond
+func instrumentServer(t *testing.T) *tdamtest.Server {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "instruments_response.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fixture map[string]*tdam.MarketInstrument
+	if err := json.Unmarshal(b, &fixture); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := tdamtest.NewServer()
+	srv.Update(func(st *tdamtest.State) {
+		st.Instruments = fixture
+		st.Instruments["912810SS8"] = &tdam.MarketInstrument{
+			InstrumentInfo: tdam.InstrumentInfo{
+				AssetType:   tdam.BOND,
+				Cusip:       "912810SS8",
+				Symbol:      "912810SS8",
+				Description: "US TREASURY BOND 1.625% 11/15/2050",
+			},
+			Exchange:  "Bonds",
+			BondPrice: 87.2,
+		}
+	})
+	return srv
+}
+
+func TestSearchInstruments(t *testing.T) {
+	srv := instrumentServer(t)
+	defer srv.Close()
+	c := srv.Client()
+
+	found, err := c.SearchInstruments("AAPL", tdam.ProjectionFundamental)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aapl := found["AAPL"]
+	if len(found) != 1 || aapl == nil || aapl.AssetType != tdam.EQUITY || aapl.Cusip != "037833100" || aapl.Exchange != "NASDAQ" {
+		t.Fatalf("unexpected instruments %+v", found)
+	}
+	f := aapl.Fundamental
+	if f == nil {
+		t.Fatal("no fundamentals")
+	}
+	if f.High52 != tdam.Dollars(145.09) || f.Low52 != tdam.Dollars(53.1525) || f.PeRatio != 33.71836 || f.DividendYield != 0.66 || f.Beta != 1.23464 || f.MarketCap != 2091958 {
+		t.Errorf("unexpected fundamentals %+v", f)
+	}
+	if want := time.Date(2021, 2, 5, 0, 0, 0, 0, calendar.NewYork); !f.DividendTime().Equal(want) {
+		t.Errorf("dividend date %v, want %v", f.DividendTime(), want)
+	}
+	if want := time.Date(2021, 2, 11, 0, 0, 0, 0, calendar.NewYork); !f.DividendPayTime().Equal(want) {
+		t.Errorf("dividend pay date %v, want %v", f.DividendPayTime(), want)
+	}
+	if !(&tdam.Fundamental{DividendDate: " "}).DividendTime().IsZero() {
+		t.Error("expected a blank dividend date to be zero")
+	}
+
+	for _, tc := range []struct {
+		query      string
+		projection tdam.Projection
+		want       string
+	}{
+		{"AAPL", tdam.ProjectionSymbolSearch, "AAPL"},
+		{"AA.*", tdam.ProjectionSymbolRegex, "AAPL"},
+		{"A", tdam.ProjectionSymbolRegex, ""},
+		{"treasury", tdam.ProjectionDescSearch, "912810SS8"},
+		{"^Apple", tdam.ProjectionDescRegex, "AAPL"},
+	} {
+		found, err := c.SearchInstruments(tc.query, tc.projection)
+		if err != nil {
+			t.Errorf("%s %s: %v", tc.projection, tc.query, err)
+			continue
+		}
+		if (tc.want == "" && len(found) != 0) || (tc.want != "" && (len(found) != 1 || found[tc.want] == nil)) {
+			t.Errorf("%s %s: got %v, want %q", tc.projection, tc.query, found, tc.want)
+		}
+		for _, i := range found {
+			if i.Fundamental != nil {
+				t.Errorf("%s %s: fundamentals without asking", tc.projection, tc.query)
+			}
+		}
+	}
+
+	requests := len(srv.Requests())
+	if _, err := c.SearchInstruments("AAPL", "fundamentals"); err == nil {
+		t.Error("expected an invalid projection to fail")
+	}
+	if _, err := c.SearchInstruments("", tdam.ProjectionSymbolSearch); err == nil {
+		t.Error("expected an empty query to fail")
+	}
+	if len(srv.Requests()) != requests {
+		t.Error("invalid searches shouldn't be sent")
+	}
+}
+
+func TestGetInstrumentByCUSIP(t *testing.T) {
+	srv := instrumentServer(t)
+	defer srv.Close()
+	c := srv.Client()
+
+	bond, err := c.GetInstrumentByCUSIP("912810SS8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bond.AssetType != tdam.BOND || bond.BondPrice != 87.2 || bond.Fundamental != nil {
+		t.Errorf("unexpected instrument %+v", bond)
+	}
+	if reqs := srv.Requests(); reqs[len(reqs)-1] != "GET /v1/instruments/912810SS8" {
+		t.Errorf("unexpected request %s", reqs[len(reqs)-1])
+	}
+
+	if _, err := c.GetInstrumentByCUSIP("000000000"); err == nil {
+		t.Error("expected an unknown cusip to fail")
+	}
+}
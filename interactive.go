@@ -0,0 +1,217 @@
+package tdam
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+const DefaultAuthorizeTimeout = 5 * time.Minute
+
+type AuthorizeOptions struct {
+	// address to listen on.  Defaults to the host of the client's RedirectURI
+	Addr string
+
+	// tls certificate for the listener.  If empty, a self-signed
+	// certificate for localhost is generated
+	CertFile string
+	KeyFile  string
+
+	// how long to wait for the user to complete the login.
+	// Defaults to DefaultAuthorizeTimeout
+	Timeout time.Duration
+
+	// try to open the authorize url in the system browser
+	OpenBrowser bool
+
+	// where the authorize url is printed.  Defaults to os.Stdout
+	Out io.Writer
+}
+
+type authResult struct {
+	code string
+	err  error
+}
+
+// AuthorizeInteractive runs a first-time oauth login: it listens on the
+// client's redirect uri, prints (and optionally opens) the authorize url,
+// waits for the callback, exchanges the code for a token and saves it to
+// the client's TokenStore.
+func AuthorizeInteractive(ctx context.Context, c *Client, opts AuthorizeOptions) (*TokenResponse, error) {
+	redirect, err := url.Parse(c.redirectURI())
+	if err != nil {
+		return nil, fmt.Errorf("invalid redirect uri: %v", err)
+	}
+	if redirect.Scheme != "https" {
+		return nil, fmt.Errorf("redirect uri must be https: %s", redirect)
+	}
+
+	addr := opts.Addr
+	if addr == "" {
+		addr = redirect.Host
+		if redirect.Port() == "" {
+			addr = net.JoinHostPort(redirect.Hostname(), "443")
+		}
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultAuthorizeTimeout
+	}
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	var cert tls.Certificate
+	if opts.CertFile != "" {
+		cert, err = tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+	} else {
+		cert, err = selfSignedCert(redirect.Hostname())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := make([]byte, 16)
+	if _, err := rand.Read(state); err != nil {
+		return nil, err
+	}
+	stateStr := hex.EncodeToString(state)
+
+	results := make(chan authResult, 1)
+	mux := http.NewServeMux()
+	path := redirect.Path
+	if path == "" {
+		path = "/"
+	}
+	mux.HandleFunc(path, func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		var res authResult
+		switch {
+		case query.Get("error") != "":
+			res.err = fmt.Errorf("authorization failed: %s", query.Get("error"))
+		case query.Get("state") != stateStr:
+			http.Error(w, "unexpected state", http.StatusBadRequest)
+			return
+		case query.Get("code") == "":
+			res.err = fmt.Errorf("callback missing code")
+		default:
+			res.code = query.Get("code")
+		}
+
+		w.Header().Set("Content-Type", "text/plain")
+		if res.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "%v\n", res.err)
+		} else {
+			fmt.Fprintf(w, "authorization received, you may close this window\n")
+		}
+		select {
+		case results <- res:
+		default:
+		}
+	})
+
+	ln, err := tls.Listen("tcp", addr, &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(ln)
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	authURL := c.AuthURL(stateStr)
+	fmt.Fprintf(out, "To authorize, visit %s\n", authURL)
+	if opts.OpenBrowser {
+		if err := openBrowser(authURL); err != nil {
+			fmt.Fprintf(out, "couldn't open browser: %v\n", err)
+		}
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var res authResult
+	select {
+	case res = <-results:
+	case <-timer.C:
+		return nil, fmt.Errorf("timed out after %s waiting for authorization", timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	token, err := c.getToken(ctx, res.code)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.SetToken(token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func selfSignedCert(host string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{Organization: []string{"tdam"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+	} else if host != "" && host != "localhost" {
+		tmpl.DNSNames = append(tmpl.DNSNames, host)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+func openBrowser(u string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", u).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", u).Start()
+	default:
+		return exec.Command("xdg-open", u).Start()
+	}
+}
@@ -0,0 +1,71 @@
+package tdam
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthorizeInteractive(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		fmt.Fprintf(w, `{"access_token":"access-%s","refresh_token":"refresh","expires_in":1800}`, req.Form.Get("code"))
+	}))
+	defer tokenServer.Close()
+
+	// grab a free port for the callback listener
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	c := NewClient("KEY")
+	c.TokenURL = tokenServer.URL
+	c.RedirectURI = "https://" + addr + "/auth"
+	c.TokenStore = NewMemoryTokenStore()
+
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		_, err := AuthorizeInteractive(context.Background(), c, AuthorizeOptions{Out: pw, Timeout: 10 * time.Second})
+		done <- err
+	}()
+
+	line, err := bufio.NewReader(pr).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	go io.Copy(ioutil.Discard, pr)
+	authURL, err := url.Parse(strings.TrimSpace(strings.TrimPrefix(line, "To authorize, visit ")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := authURL.Query().Get("state")
+
+	insecure := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	resp, err := insecure.Get(c.RedirectURI + "?code=xyz&state=" + state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := c.TokenStore.LoadToken()
+	if stored == nil || stored.AccessToken != "access-xyz" {
+		t.Errorf("token not persisted: %#v", stored)
+	}
+}
@@ -0,0 +1,333 @@
+package tdam
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// the pages of TD's login flow, identified by their title
+type loginStep int
+
+const (
+	stepUnknown loginStep = iota
+	stepCredentials
+	stepChooseFactor
+	stepSecurityQuestion
+	stepSMSCode
+	stepAuthorize
+)
+
+var loginStepTitles = map[string]loginStep{
+	"Secure Log-in":               stepCredentials,
+	"Get Code via Text Message":   stepChooseFactor,
+	"Answer Security Question":    stepSecurityQuestion,
+	"Enter Code":                  stepSMSCode,
+	"TD Ameritrade Authorization": stepAuthorize,
+}
+
+func (s loginStep) String() string {
+	for title, step := range loginStepTitles {
+		if step == s {
+			return title
+		}
+	}
+	return "unknown"
+}
+
+// the login flow never takes more than a handful of pages
+const maxLoginSteps = 10
+
+func pageTitle(doc *goquery.Document) string {
+	return strings.TrimSpace(doc.Find("div.title>h1").First().Text())
+}
+
+func classifyLoginPage(doc *goquery.Document) (loginStep, error) {
+	title := pageTitle(doc)
+	if step, ok := loginStepTitles[title]; ok {
+		return step, nil
+	}
+	return stepUnknown, fmt.Errorf("unrecognized login page %q", title)
+}
+
+// the prompt for the current second factor step
+func pageDescription(doc *goquery.Document) string {
+	return strings.TrimSpace(doc.Find("div.description>p").Last().Text())
+}
+
+func pageError(doc *goquery.Document) string {
+	msg := strings.TrimSpace(doc.Find(".alert, .error").First().Text())
+	if msg == "" {
+		return "no error message on page"
+	}
+	return msg
+}
+
+// loginForm is the #authform on a login page, with its current values
+type loginForm struct {
+	method string
+	action *url.URL
+	values url.Values
+}
+
+func parseLoginForm(doc *goquery.Document, page *url.URL) (*loginForm, error) {
+	sel := doc.Find("form#authform").First()
+	if sel.Length() == 0 {
+		return nil, fmt.Errorf("no #authform on page %q", pageTitle(doc))
+	}
+
+	action, err := page.Parse(sel.AttrOr("action", ""))
+	if err != nil {
+		return nil, err
+	}
+	f := &loginForm{
+		method: strings.ToUpper(sel.AttrOr("method", "POST")),
+		action: action,
+		values: url.Values{},
+	}
+	sel.Find("input").Each(func(_ int, in *goquery.Selection) {
+		name, ok := in.Attr("name")
+		if !ok {
+			return
+		}
+		switch strings.ToLower(in.AttrOr("type", "text")) {
+		case "submit", "button", "image":
+			// only sent for the button that was clicked
+		case "checkbox", "radio":
+			if _, checked := in.Attr("checked"); checked {
+				f.values.Add(name, in.AttrOr("value", "on"))
+			}
+		default:
+			f.values.Add(name, in.AttrOr("value", ""))
+		}
+	})
+	return f, nil
+}
+
+// button value for a named submit button on the form, if present
+func formButton(doc *goquery.Document, name string) (string, bool) {
+	sel := doc.Find(fmt.Sprintf(`form#authform [name="%s"]`, name)).First()
+	if sel.Length() == 0 {
+		return "", false
+	}
+	return sel.AttrOr("value", ""), true
+}
+
+type loginFlow struct {
+	c        *Client
+	http     *http.Client
+	username string
+	password string
+	factor   SecondFactorProvider
+
+	doc  *goquery.Document
+	page *url.URL
+	code string // set once we've been redirected to the redirect uri
+}
+
+func (c *Client) newLoginFlow(username, password string, factor SecondFactorProvider) (*loginFlow, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	redirect := c.redirectURI()
+
+	base := c.httpClient()
+	return &loginFlow{
+		c: c,
+		http: &http.Client{
+			Transport: base.Transport,
+			Timeout:   base.Timeout,
+			Jar:       jar,
+			// stop at the redirect uri and take the code from there,
+			// rather than needing a callback server to be listening
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if strings.HasPrefix(req.URL.String(), redirect) {
+					return http.ErrUseLastResponse
+				}
+				if len(via) >= 10 {
+					return fmt.Errorf("stopped after 10 redirects")
+				}
+				return nil
+			},
+		},
+		username: username,
+		password: password,
+		factor:   factor,
+	}, nil
+}
+
+func (f *loginFlow) load(req *http.Request) error {
+	resp, err := f.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if loc := resp.Header.Get("Location"); loc != "" && resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		u, err := resp.Request.URL.Parse(loc)
+		if err != nil {
+			return err
+		}
+		if e := u.Query().Get("error"); e != "" {
+			return fmt.Errorf("authorization failed: %s", e)
+		}
+		if f.code = u.Query().Get("code"); f.code == "" {
+			return fmt.Errorf("redirected to %s without a code", u.Path)
+		}
+		return nil
+	}
+	if err := CheckResponse(resp); err != nil {
+		return err
+	}
+
+	f.doc, err = goquery.NewDocumentFromReader(resp.Body)
+	f.page = resp.Request.URL
+	return err
+}
+
+func (f *loginFlow) submit(ctx context.Context, form *loginForm) error {
+	var req *http.Request
+	var err error
+	if form.method == "GET" {
+		u := *form.action
+		u.RawQuery = form.values.Encode()
+		req, err = http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, form.method, form.action.String(), strings.NewReader(form.values.Encode()))
+		if req != nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return f.load(req)
+}
+
+// chooses the first factor offered by both the page and the provider
+func (f *loginFlow) chooseFactor() (SecondFactor, string, error) {
+	if f.factor == nil {
+		return "", "", fmt.Errorf("a second factor is required but no SecondFactorProvider is configured")
+	}
+	for _, factor := range f.factor.Factors() {
+		button := "init_" + string(factor)
+		if _, ok := formButton(f.doc, button); ok {
+			return factor, button, nil
+		}
+	}
+	return "", "", fmt.Errorf("none of the offered second factors are supported by the provider")
+}
+
+// step handles the current page and loads the next one
+func (f *loginFlow) step(ctx context.Context) error {
+	step, err := classifyLoginPage(f.doc)
+	if err != nil {
+		return err
+	}
+	form, err := parseLoginForm(f.doc, f.page)
+	if err != nil {
+		return err
+	}
+
+	switch step {
+	case stepCredentials:
+		form.values.Set("su_username", f.username)
+		form.values.Set("su_password", f.password)
+
+	case stepChooseFactor:
+		_, button, err := f.chooseFactor()
+		if err != nil {
+			return err
+		}
+		value, _ := formButton(f.doc, button)
+		form.values.Set(button, value)
+
+	case stepSecurityQuestion, stepSMSCode:
+		factor, field := SecurityQuestion, "su_secretquestion"
+		if step == stepSMSCode {
+			factor, field = SMSCode, "su_smscode"
+		}
+		if f.factor == nil {
+			return fmt.Errorf("a second factor is required but no SecondFactorProvider is configured")
+		}
+		answer, err := f.factor.Answer(factor, pageDescription(f.doc))
+		if err != nil {
+			return err
+		}
+		form.values.Set(field, answer)
+
+	case stepAuthorize:
+		if value, ok := formButton(f.doc, "accept"); ok {
+			form.values.Set("accept", value)
+		}
+	}
+
+	return f.submit(ctx, form)
+}
+
+// run drives the flow from the authorize url to the redirect, returning the code
+func (f *loginFlow) run(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", f.c.TdamAuthURL(), nil)
+	if err != nil {
+		return "", err
+	}
+	if err := f.load(req); err != nil {
+		return "", err
+	}
+
+	prev := stepUnknown
+	for i := 0; f.code == ""; i++ {
+		if i >= maxLoginSteps {
+			return "", fmt.Errorf("login didn't complete after %d pages", maxLoginSteps)
+		}
+		// landing on the same page again means our answer was rejected
+		step, _ := classifyLoginPage(f.doc)
+		if step != stepUnknown && step == prev {
+			return "", fmt.Errorf("login rejected at %q: %s", step, pageError(f.doc))
+		}
+		prev = step
+		if err := f.step(ctx); err != nil {
+			return "", err
+		}
+	}
+	return f.code, nil
+}
+
+// Login performs TD's oauth login without a browser, answering any second
+// factor challenge with factor, and stores the resulting token.
+func (c *Client) Login(ctx context.Context, username, password string, factor SecondFactorProvider) error {
+	flow, err := c.newLoginFlow(username, password, factor)
+	if err != nil {
+		return err
+	}
+	code, err := flow.run(ctx)
+	if err != nil {
+		return err
+	}
+	token, err := c.getToken(ctx, code)
+	if err != nil {
+		return err
+	}
+	return c.SetToken(token)
+}
+
+func (c *Client) slogThroughOauthFlow(ctx context.Context) error {
+	username := os.Getenv("TDAM_USERNAME")
+	password := os.Getenv("TDAM_PASSWORD")
+	if username == "" || password == "" {
+		return fmt.Errorf("must set TDAM_USERNAME and TDAM_PASSWORD for automatic oauth")
+	}
+
+	factor := c.SecondFactor
+	if factor == nil {
+		factor = EnvSecurityQuestions()
+	}
+	return c.Login(ctx, username, password, factor)
+}
@@ -0,0 +1,184 @@
+package tdam
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func loadLoginFixture(t *testing.T, name string) *goquery.Document {
+	f, err := os.Open(filepath.Join("testdata", "login", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	doc, err := goquery.NewDocumentFromReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestClassifyLoginPages(t *testing.T) {
+	for fixture, want := range map[string]loginStep{
+		"login.html":             stepCredentials,
+		"login_error.html":       stepCredentials,
+		"choose_factor.html":     stepChooseFactor,
+		"security_question.html": stepSecurityQuestion,
+		"sms_code.html":          stepSMSCode,
+		"authorize.html":         stepAuthorize,
+	} {
+		step, err := classifyLoginPage(loadLoginFixture(t, fixture))
+		if err != nil {
+			t.Errorf("%s: %v", fixture, err)
+		}
+		if step != want {
+			t.Errorf("%s: got %s, want %s", fixture, step, want)
+		}
+	}
+
+	if _, err := classifyLoginPage(loadLoginFixture(t, "changed_title.html")); err == nil ||
+		!strings.Contains(err.Error(), "Verify Your Identity") {
+		t.Errorf("expected unrecognized page error naming the title, got %v", err)
+	}
+}
+
+func TestSecurityQuestionsMatchFixture(t *testing.T) {
+	desc := pageDescription(loadLoginFixture(t, "security_question.html"))
+	answer, err := EnvSecurityQuestions().Answer(SecurityQuestion, desc)
+	if answer != "" || err == nil {
+		t.Errorf("expected no answer without env, got %q, %v", answer, err)
+	}
+
+	sq := SecurityQuestions{"What is your best friend's first name?": "Bob"}
+	if answer, err := sq.Answer(SecurityQuestion, desc); err != nil || answer != "Bob" {
+		t.Errorf("got %q, %v", answer, err)
+	}
+}
+
+// fakeLoginServer walks through the fixtures the way TD's login pages do
+func fakeLoginServer(t *testing.T, redirectURI string) *httptest.Server {
+	serve := func(w http.ResponseWriter, name string) {
+		b, err := ioutil.ReadFile(filepath.Join("testdata", "login", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write(b)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth", func(w http.ResponseWriter, req *http.Request) {
+		serve(w, "login.html")
+	})
+	mux.HandleFunc("/auth", func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		switch req.Form.Get("_step") {
+		case "login":
+			if req.Form.Get("su_username") == "user" && req.Form.Get("su_password") == "secret" {
+				serve(w, "choose_factor.html")
+			} else {
+				serve(w, "login_error.html")
+			}
+		case "choose":
+			if req.Form.Get("init_secretquestion") != "" {
+				serve(w, "security_question.html")
+			} else {
+				serve(w, "sms_code.html")
+			}
+		case "question":
+			if req.Form.Get("su_secretquestion") == "Bob" {
+				serve(w, "authorize.html")
+			} else {
+				serve(w, "security_question.html")
+			}
+		case "sms":
+			if req.Form.Get("su_smscode") == "123456" {
+				serve(w, "authorize.html")
+			} else {
+				serve(w, "sms_code.html")
+			}
+		case "authorize":
+			if req.Form.Get("accept") == "" {
+				t.Errorf("authorize page submitted without accept")
+			}
+			http.Redirect(w, req, redirectURI+"?code=the-code", http.StatusFound)
+		default:
+			http.Error(w, "bad step", http.StatusBadRequest)
+		}
+	})
+	mux.HandleFunc("/v1/oauth2/token", func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		fmt.Fprintf(w, `{"access_token":"access-%s","refresh_token":"refresh","expires_in":1800}`, req.Form.Get("code"))
+	})
+	return httptest.NewServer(mux)
+}
+
+func newLoginTestClient(t *testing.T) (*Client, func()) {
+	redirect := "https://127.0.0.1:8443/auth"
+	srv := fakeLoginServer(t, redirect)
+	c := NewClient("KEY")
+	c.AuthBaseURL = srv.URL
+	c.TokenURL = srv.URL + "/v1/oauth2/token"
+	c.RedirectURI = redirect
+	c.TokenStore = NewMemoryTokenStore()
+	return c, srv.Close
+}
+
+func TestLoginSecurityQuestion(t *testing.T) {
+	c, done := newLoginTestClient(t)
+	defer done()
+
+	sq := SecurityQuestions{"What is your best friend's first name?": "Bob"}
+	if err := c.Login(context.Background(), "user", "secret", sq); err != nil {
+		t.Fatal(err)
+	}
+	if token, err := c.TDAMToken(); err != nil || token != "access-the-code" {
+		t.Errorf("got token %q, %v", token, err)
+	}
+}
+
+func TestLoginSMSCode(t *testing.T) {
+	c, done := newLoginTestClient(t)
+	defer done()
+
+	var prompt string
+	sms := SMSCodeFunc(func(p string) (string, error) {
+		prompt = p
+		return "123456", nil
+	})
+	if err := c.Login(context.Background(), "user", "secret", sms); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(prompt, "ending in 1234") {
+		t.Errorf("sms prompt was %q", prompt)
+	}
+}
+
+func TestLoginFailures(t *testing.T) {
+	c, done := newLoginTestClient(t)
+	defer done()
+
+	err := c.Login(context.Background(), "user", "wrong", SecurityQuestions{})
+	if err == nil || !strings.Contains(err.Error(), "incorrect") {
+		t.Errorf("expected rejected login, got %v", err)
+	}
+
+	err = c.Login(context.Background(), "user", "secret", SecurityQuestions{"What is your favorite color?": "blue"})
+	if err == nil || !strings.Contains(err.Error(), "best friend") {
+		t.Errorf("expected unknown question error, got %v", err)
+	}
+
+	err = c.Login(context.Background(), "user", "secret", &StdinPrompt{In: strings.NewReader("Alice\n"), Out: ioutil.Discard})
+	if err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Errorf("expected wrong answer to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package tdam
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ianmcmahon/tdam/calendar"
+)
+
+type Market string
+
+const (
+	MarketEquity Market = "EQUITY"
+	MarketOption Market = "OPTION"
+	MarketFuture Market = "FUTURE"
+	MarketBond   Market = "BOND"
+	MarketForex  Market = "FOREX"
+)
+
+// MarketHours is one product's hours on a day.  Sessions are empty on days
+// the market is closed.
+type MarketHours struct {
+	Date         string       `json:"date"`
+	MarketType   Market       `json:"marketType"`
+	Exchange     string       `json:"exchange"`
+	Category     string       `json:"category"`
+	Product      string       `json:"product"`
+	ProductName  string       `json:"productName"`
+	IsOpen       bool         `json:"isOpen"`
+	SessionHours SessionHours `json:"sessionHours"`
+}
+
+type SessionHours struct {
+	PreMarket     []calendar.Interval `json:"preMarket"`
+	RegularMarket []calendar.Interval `json:"regularMarket"`
+	PostMarket    []calendar.Interval `json:"postMarket"`
+}
+
+// SessionAt is the session in progress at t
+func (h *MarketHours) SessionAt(t time.Time) calendar.Session {
+	for _, s := range []struct {
+		session   calendar.Session
+		intervals []calendar.Interval
+	}{
+		{calendar.PreMarket, h.SessionHours.PreMarket},
+		{calendar.Regular, h.SessionHours.RegularMarket},
+		{calendar.PostMarket, h.SessionHours.PostMarket},
+	} {
+		for _, i := range s.intervals {
+			if i.Contains(t) {
+				return s.session
+			}
+		}
+	}
+	return calendar.Closed
+}
+
+// GetMarketHours gets the hours of markets on date, by market and then
+// product, eg hours[tdam.MarketOption]["EQO"].  The calendar package
+// answers the same questions for stocks and options without a request.
+func (c *Client) GetMarketHours(markets []Market, date time.Time) (map[Market]map[string]*MarketHours, error) {
+	return c.GetMarketHoursContext(context.Background(), markets, date)
+}
+
+func (c *Client) GetMarketHoursContext(ctx context.Context, markets []Market, date time.Time) (map[Market]map[string]*MarketHours, error) {
+	names := make([]string, len(markets))
+	for i, m := range markets {
+		names[i] = string(m)
+	}
+	query := url.Values{}
+	query.Set("markets", strings.Join(names, ","))
+	query.Set("date", date.Format("2006-01-02"))
+
+	// keyed by the market in lower case
+	var raw map[string]map[string]*MarketHours
+	if err := c.doMarketDataJSON(ctx, Request{
+		Endpoint: "/v1/marketdata/hours",
+		Query:    query,
+	}, &raw); err != nil {
+		return nil, err
+	}
+
+	hours := make(map[Market]map[string]*MarketHours, len(raw))
+	for market, products := range raw {
+		hours[Market(strings.ToUpper(market))] = products
+	}
+	return hours, nil
+}
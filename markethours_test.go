@@ -0,0 +1,72 @@
+package tdam_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ianmcmahon/tdam"
+	"github.com/ianmcmahon/tdam/calendar"
+	"github.com/ianmcmahon/tdam/tdamtest"
+)
+
+func TestGetMarketHours(t *testing.T) {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "hours_response.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fixture map[string]map[string]*tdam.MarketHours
+	if err := json.Unmarshal(b, &fixture); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := tdamtest.NewServer()
+	defer srv.Close()
+	srv.Update(func(st *tdamtest.State) {
+		for _, products := range fixture {
+			for _, h := range products {
+				st.Hours = append(st.Hours, h)
+			}
+		}
+	})
+
+	c := srv.Client()
+	hours, err := c.GetMarketHours([]tdam.Market{tdam.MarketEquity, tdam.MarketOption}, calendar.Date(2021, time.March, 12))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eq := hours[tdam.MarketEquity]["EQ"]
+	if eq == nil || !eq.IsOpen {
+		t.Fatalf("unexpected equity hours %#v", hours[tdam.MarketEquity])
+	}
+	// the offline calendar should agree with TD
+	day, _ := calendar.TradingDay(calendar.Date(2021, time.March, 12))
+	if !eq.SessionHours.RegularMarket[0].Start.Equal(day.Regular.Start) || !eq.SessionHours.PostMarket[0].End.Equal(day.PostMarket.End) {
+		t.Errorf("equity hours %+v don't match calendar %+v", eq.SessionHours, day)
+	}
+
+	ind := hours[tdam.MarketOption]["IND"]
+	late := time.Date(2021, time.March, 12, 21, 10, 0, 0, time.UTC)
+	if ind == nil || ind.SessionAt(late) != calendar.Regular || eq.SessionAt(late) != calendar.PostMarket {
+		t.Errorf("index options should trade until 4:15")
+	}
+
+	hours, err = c.GetMarketHours([]tdam.Market{tdam.MarketOption}, calendar.Date(2021, time.March, 15))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hours) != 0 {
+		t.Errorf("expected nothing for another day, got %v", hours)
+	}
+}
+
+func TestTradingDTE(t *testing.T) {
+	exp := tdam.ExpirationDate("2021-04-09:9")
+	now := time.Date(2021, time.March, 31, 15, 0, 0, 0, time.UTC)
+	if got := exp.TradingDTE(now); got != 6 {
+		t.Errorf("trading dte %d, want 6", got)
+	}
+}
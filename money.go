@@ -0,0 +1,279 @@
+package tdam
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is a fixed-point dollar amount counted in ten-thousandths of a
+// dollar, enough for TD's sub-penny quotes, so sums of fills, fees and
+// balances come out to the penny.  Build values with Dollars, ParseMoney
+// or the Cent and Dollar constants: Money(50) is half a cent, not $50.
+type Money int64
+
+// Price is a per share or per contract Money amount
+type Price = Money
+
+const (
+	moneyPlaces       = 4
+	moneyScale  Money = 10000
+
+	Cent   Money = moneyScale / 100
+	Nickel Money = 5 * Cent
+	Dime   Money = 10 * Cent
+	Dollar Money = moneyScale
+)
+
+// Dollars converts a float to Money, rounding to the nearest ten-thousandth
+func Dollars(f float64) Money {
+	return Money(math.Round(f * float64(moneyScale)))
+}
+
+// ParseMoney parses a decimal amount like "-1234.5" or "0.0125" exactly.
+// A leading $ and thousands separators are allowed.  Digits past the
+// fourth decimal place are rounded.
+func ParseMoney(s string) (Money, error) {
+	orig := s
+	s = strings.Replace(strings.TrimSpace(s), ",", "", -1)
+	neg := false
+	if strings.HasPrefix(s, "-") {
+		neg, s = true, s[1:]
+	} else if strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+	s = strings.TrimPrefix(s, "$")
+
+	if strings.ContainsAny(s, "eE") {
+		// exponent notation, which json sometimes gives us for tiny values
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid money amount %q", orig)
+		}
+		m := Dollars(f)
+		if neg {
+			m = -m
+		}
+		return m, nil
+	}
+
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	}
+	if whole == "" && frac == "" || !allDigits(whole) || !allDigits(frac) {
+		return 0, fmt.Errorf("invalid money amount %q", orig)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid money amount %q", orig)
+	}
+
+	roundUp := false
+	if len(frac) > moneyPlaces {
+		roundUp = frac[moneyPlaces] >= '5'
+		frac = frac[:moneyPlaces]
+	}
+	frac += strings.Repeat("0", moneyPlaces-len(frac))
+	f, _ := strconv.ParseInt(frac, 10, 64)
+
+	m := Money(w)*moneyScale + Money(f)
+	if roundUp {
+		m++
+	}
+	if neg {
+		m = -m
+	}
+	return m, nil
+}
+
+func allDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Money) Float64() float64 {
+	return float64(m) / float64(moneyScale)
+}
+
+// Mul multiplies by a quantity, like shares.  Whole quantities are exact,
+// fractional ones are rounded to the nearest ten-thousandth.
+func (m Money) Mul(quantity float64) Money {
+	if q := math.Trunc(quantity); q == quantity && math.Abs(q) < 1<<53 {
+		return m * Money(q)
+	}
+	return Dollars(m.Float64() * quantity)
+}
+
+// Div divides into n parts, rounding half away from zero
+func (m Money) Div(n int64) Money {
+	return roundDiv(m, Money(n))
+}
+
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// Round rounds to the nearest multiple of tick, halves away from zero
+func (m Money) Round(tick Money) Money {
+	if tick <= 0 {
+		return m
+	}
+	return roundDiv(m, tick) * tick
+}
+
+// RoundDown rounds toward zero to a multiple of tick
+func (m Money) RoundDown(tick Money) Money {
+	if tick <= 0 {
+		return m
+	}
+	return m / tick * tick
+}
+
+// RoundUp rounds away from zero to a multiple of tick
+func (m Money) RoundUp(tick Money) Money {
+	if tick <= 0 {
+		return m
+	}
+	if d := m.RoundDown(tick); d != m {
+		if m < 0 {
+			return d - tick
+		}
+		return d + tick
+	}
+	return m
+}
+
+func roundDiv(a, b Money) Money {
+	q, r := a/b, a%b
+	if r < 0 {
+		r = -r
+	}
+	if 2*r >= b.Abs() {
+		if (a < 0) != (b < 0) {
+			q--
+		} else {
+			q++
+		}
+	}
+	return q
+}
+
+// String formats with at least two decimal places, and more only when
+// there are sub-penny digits: "1.05", "-0.0125", "150.00"
+func (m Money) String() string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+	}
+	abs := uint64(m)
+	if m < 0 {
+		abs = uint64(-m)
+	}
+	whole, frac := abs/uint64(moneyScale), abs%uint64(moneyScale)
+	f := strings.TrimRight(fmt.Sprintf("%04d", frac), "0")
+	for len(f) < 2 {
+		f += "0"
+	}
+	return fmt.Sprintf("%s%d.%s", sign, whole, f)
+}
+
+// Dollars formats as dollars and cents with separators, eg "-$1,234.56"
+func (m Money) Dollars() string {
+	s := m.Round(Cent).String()
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, cents := s[:len(s)-3], s[len(s)-3:]
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return sign + "$" + whole + cents
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON takes a number or a quoted number.  null and TD's "NaN"
+// leave the amount unchanged.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" || s == `"NaN"` {
+		return nil
+	}
+	s = strings.Trim(s, `"`)
+	v, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// UnmarshalText parses a plain decimal amount, as found in XML
+func (m *Money) UnmarshalText(b []byte) error {
+	v, err := ParseMoney(string(b))
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// TickRule is an option's minimum price increment schedule
+type TickRule int
+
+const (
+	// $0.05 under $3, $0.10 from $3
+	NickelDime TickRule = iota
+	// the penny program: $0.01 under $3, $0.05 from $3
+	PennyNickel
+	// $0.01 at every price, eg SPY, QQQ and IWM
+	AllPenny
+)
+
+// Tick is the price increment at price
+func (r TickRule) Tick(price Money) Money {
+	under3 := price.Abs() < 3*Dollar
+	switch r {
+	case AllPenny:
+		return Cent
+	case PennyNickel:
+		if under3 {
+			return Cent
+		}
+		return Nickel
+	default:
+		if under3 {
+			return Nickel
+		}
+		return Dime
+	}
+}
+
+// Round rounds price to the nearest valid increment
+func (r TickRule) Round(price Money) Money {
+	return price.Round(r.Tick(price))
+}
+
+// EquityTick is the increment stocks trade in: pennies, or
+// ten-thousandths under a dollar
+func EquityTick(price Money) Money {
+	if price.Abs() < Dollar {
+		return 1
+	}
+	return Cent
+}
@@ -0,0 +1,130 @@
+package tdam
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	for in, want := range map[string]Money{
+		"1.05":       Dollars(1.05),
+		"-0.0125":    -125,
+		"150":        150 * Dollar,
+		".5":         50 * Cent,
+		"$1,234.56":  123456 * Cent,
+		"-$20.00":    -20 * Dollar,
+		"0.00005":    1,
+		"0.00004":    0,
+		"2.3999999":  Dollars(2.40),
+		"1.5e-3":     15,
+		"0.1":        10 * Cent,
+		"1234567.89": Dollars(1234567.89),
+	} {
+		got, err := ParseMoney(in)
+		if err != nil || got != want {
+			t.Errorf("ParseMoney(%q) = %d, %v, want %d", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "abc", "1.2.3", "--5", "1.-5", "."} {
+		if _, err := ParseMoney(bad); err == nil {
+			t.Errorf("expected error parsing %q", bad)
+		}
+	}
+}
+
+func TestMoneyFormat(t *testing.T) {
+	for m, want := range map[Money]string{
+		Dollars(1.05):         "1.05",
+		150 * Dollar:          "150.00",
+		-125:                  "-0.0125",
+		Dollars(-1234567.891): "-1234567.891",
+		0:                     "0.00",
+	} {
+		if got := m.String(); got != want {
+			t.Errorf("%d: got %q, want %q", int64(m), got, want)
+		}
+	}
+	if got := Dollars(-1234567.891).Dollars(); got != "-$1,234,567.89" {
+		t.Errorf("got %q", got)
+	}
+	if got := Dollars(999.995).Dollars(); got != "$1,000.00" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	// the float64 version of this drifts
+	var sum Money
+	for i := 0; i < 1000; i++ {
+		sum += Dollars(0.01)
+	}
+	if sum != 10*Dollar {
+		t.Errorf("got %s", sum)
+	}
+	if got := Dollars(1.34).Mul(100); got != 134*Dollar {
+		t.Errorf("got %s", got)
+	}
+	// too many digits for a float64, whole quantities stay exact
+	if big := Money(1<<53 + 1); big.Mul(3) != 3*big || big.Mul(-1) != -big {
+		t.Errorf("got %d", big.Mul(3))
+	}
+	if got := Dollar.Mul(0.5); got != 50*Cent {
+		t.Errorf("got %s", got)
+	}
+	if got := (10 * Dollar).Div(3); got != Dollars(3.3333) {
+		t.Errorf("got %s", got)
+	}
+	if got := (-10 * Dollar).Div(-4); got != Dollars(2.5) {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestTickRounding(t *testing.T) {
+	for _, tc := range []struct {
+		rule  TickRule
+		price float64
+		want  float64
+	}{
+		{NickelDime, 1.07, 1.05},
+		{NickelDime, 1.08, 1.10},
+		{NickelDime, 3.14, 3.10},
+		{NickelDime, 3.15, 3.20},
+		{PennyNickel, 1.074, 1.07},
+		{PennyNickel, 4.12, 4.10},
+		{PennyNickel, 4.13, 4.15},
+		{AllPenny, 4.126, 4.13},
+	} {
+		if got := tc.rule.Round(Dollars(tc.price)); got != Dollars(tc.want) {
+			t.Errorf("%d %g: got %s, want %g", tc.rule, tc.price, got, tc.want)
+		}
+	}
+	if got := Dollars(1.07).RoundDown(Nickel); got != Dollars(1.05) {
+		t.Errorf("got %s", got)
+	}
+	if got := Dollars(-1.07).RoundUp(Nickel); got != Dollars(-1.10) {
+		t.Errorf("got %s", got)
+	}
+	if EquityTick(Dollars(0.5)) != 1 || EquityTick(Dollars(12)) != Cent {
+		t.Errorf("unexpected equity ticks")
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var v struct {
+		A, B, C, D Money
+	}
+	v.D = 7
+	if err := json.Unmarshal([]byte(`{"A":1.05,"B":"2.50","C":1e-4,"D":"NaN"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != Dollars(1.05) || v.B != Dollars(2.5) || v.C != 1 || v.D != 7 {
+		t.Errorf("got %+v", v)
+	}
+	b, _ := json.Marshal(v)
+	if string(b) != `{"A":1.05,"B":2.50,"C":0.0001,"D":0.0007}` {
+		t.Errorf("got %s", b)
+	}
+	if err := json.Unmarshal([]byte(`{"A":"x"}`), &v); err == nil {
+		t.Errorf("expected error")
+	}
+}
@@ -0,0 +1,94 @@
+package tdam
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OptionSymbol is a parsed option symbol.  TD uses its own format,
+// SPY_121820P300, rather than the 21 character OCC one; both are parsed.
+type OptionSymbol struct {
+	Underlying string
+	Expiration time.Time
+	PutCall    ContractType
+	Strike     float64
+}
+
+var (
+	tdOptionSymbol  = regexp.MustCompile(`^([^_]+)_(\d{6})(P|C)(\d+(\.\d+)?)$`)
+	occOptionSymbol = regexp.MustCompile(`^([A-Z0-9.]{1,6}) *(\d{6})(P|C)(\d{8})$`)
+)
+
+func ParseOptionSymbol(symbol string) (OptionSymbol, error) {
+	var o OptionSymbol
+	if m := tdOptionSymbol.FindStringSubmatch(symbol); m != nil {
+		exp, err := time.Parse("010206", m[2])
+		if err != nil {
+			return o, err
+		}
+		strike, err := strconv.ParseFloat(m[4], 64)
+		if err != nil {
+			return o, err
+		}
+		o = OptionSymbol{Underlying: m[1], Expiration: exp, Strike: strike}
+		o.PutCall = putCall(m[3])
+		return o, nil
+	}
+	if m := occOptionSymbol.FindStringSubmatch(symbol); m != nil {
+		exp, err := time.Parse("060102", m[2])
+		if err != nil {
+			return o, err
+		}
+		strike, err := strconv.Atoi(m[4])
+		if err != nil {
+			return o, err
+		}
+		o = OptionSymbol{Underlying: m[1], Expiration: exp, Strike: float64(strike) / 1000}
+		o.PutCall = putCall(m[3])
+		return o, nil
+	}
+	return o, fmt.Errorf("couldn't parse option symbol %q", symbol)
+}
+
+func putCall(pc string) ContractType {
+	if pc == "P" {
+		return PUT
+	}
+	return CALL
+}
+
+// the P or C in a symbol, empty unless PutCall is PUT or CALL
+func (o OptionSymbol) putCallLetter() string {
+	switch o.PutCall {
+	case PUT:
+		return "P"
+	case CALL:
+		return "C"
+	}
+	return ""
+}
+
+// String formats the symbol the way TD does, eg SPY_121820P300.  It's
+// empty if PutCall isn't set.
+func (o OptionSymbol) String() string {
+	pc := o.putCallLetter()
+	if pc == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s_%s%s%s", o.Underlying, o.Expiration.Format("010206"),
+		pc, strconv.FormatFloat(o.Strike, 'f', -1, 64))
+}
+
+// OCC formats the symbol in the 21 character OCC format, eg "SPY   201218P00300000".
+// It's empty if PutCall isn't set.
+func (o OptionSymbol) OCC() string {
+	pc := o.putCallLetter()
+	if pc == "" {
+		return ""
+	}
+	return fmt.Sprintf("%-6s%s%s%08d", strings.ToUpper(o.Underlying), o.Expiration.Format("060102"),
+		pc, int64(o.Strike*1000+0.5))
+}
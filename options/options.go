@@ -1,16 +1,16 @@
 package options
 
 import (
-	"crypto/tls"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"io/ioutil"
 	"net/url"
 	"time"
-	"io/ioutil"
-  "bytes"
 
 	"github.com/ianmcmahon/tdam"
+	"github.com/ianmcmahon/tdam/calendar"
 )
 
 type Client struct {
@@ -18,7 +18,7 @@ type Client struct {
 }
 
 func dte(min, max int) (from, to string) {
-	now := time.Now()
+	now := time.Now().In(calendar.NewYork)
 	near := now.Add(time.Duration(min) * time.Hour * 24)
 	far := now.Add(time.Duration(max) * time.Hour * 24)
 
@@ -39,35 +39,26 @@ func Single(strikes, fromDTE, toDTE int) url.Values {
 }
 
 func (c *Client) GetChain(symbol string, options url.Values) (*OptionChain, error) {
-	transport := &http.Transport{TLSClientConfig: &tls.Config{}}
-	client := &http.Client{Transport: transport}
+	return c.GetChainContext(context.Background(), symbol, options)
+}
 
-	req, err := http.NewRequest("GET", "https://api.tdameritrade.com/v1/marketdata/chains", nil)
-	if err != nil {
-		return nil, err
+func (c *Client) GetChainContext(ctx context.Context, symbol string, options url.Values) (*OptionChain, error) {
+	query := url.Values{}
+	for k, v := range options {
+		query[k] = v
 	}
-	if options == nil {
-		options = req.URL.Query()
-	}
-	if false { //s.Authenticated {
-		token, err := c.TDAMToken()
-		if err != nil {
-			return nil, err
-		}
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-	} else {
-		options.Set("apikey", c.ConsumerKey)
-	}
-	options.Set("symbol", symbol)
-	req.URL.RawQuery = options.Encode()
+	query.Set("symbol", symbol)
 
-	resp, err := client.Do(req)
+	resp, err := c.DoContext(ctx, tdam.Request{
+		Endpoint: "/v1/marketdata/chains",
+		Query:    query,
+	})
 	if err != nil {
-		fmt.Printf("%v\n", err)
 		return nil, err
 	}
-	if resp.StatusCode != 200 {
-		fmt.Printf("status %d: %s\n", resp.StatusCode, resp.Status)
+	defer resp.Body.Close()
+	if err := tdam.CheckResponse(resp); err != nil {
+		return nil, err
 	}
 
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -7,6 +7,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/ianmcmahon/tdam/calendar"
 )
 
 type OptionChain struct {
@@ -19,7 +21,7 @@ type OptionChain struct {
 	IsIndex          bool                         `json:"isIndex"`
 	DaysToExpiration float64                      `json:"daysToExpiration"`
 	InterestRate     float64                      `json:"interestRate"`
-	UnderlyingPrice  float64                      `json:"underlyingPrice"`
+	UnderlyingPrice  Money                        `json:"underlyingPrice"`
 	Volatility       float64                      `json:"volatility"`
 	RawCalls         map[ExpirationDate]StrikeMap `json:"callExpDateMap"`
 	RawPuts          map[ExpirationDate]StrikeMap `json:"putExpDateMap"`
@@ -56,31 +58,31 @@ type Option struct {
 	Symbol                 string               `json:"symbol"`
 	Description            string               `json:"description"`
 	ExchangeName           string               `json:"exchangeName"`
-	BidPrice               float64              `json:"bid"`
-	AskPrice               float64              `json:"ask"`
-	LastPrice              float64              `json:"last"`
-	MarkPrice              float64              `json:"mark"`
+	BidPrice               Money                `json:"bid"`
+	AskPrice               Money                `json:"ask"`
+	LastPrice              Money                `json:"last"`
+	MarkPrice              Money                `json:"mark"`
 	BidSize                float64              `json:"bidSize"`
 	AskSize                float64              `json:"askSize"`
 	LastSize               float64              `json:"lastSize"`
-	HighPrice              float64              `json:"highPrice"`
-	LowPrice               float64              `json:"lowPrice"`
-	OpenPrice              float64              `json:"openPrice"`
-	ClosePrice             float64              `json:"closePrice"`
+	HighPrice              Money                `json:"highPrice"`
+	LowPrice               Money                `json:"lowPrice"`
+	OpenPrice              Money                `json:"openPrice"`
+	ClosePrice             Money                `json:"closePrice"`
 	TotalVolume            int64                `json:"totalVolume"`
 	QuoteTimeInLong        float64              `json:"quoteTimeInLong"`
 	TradeTimeInLong        float64              `json:"tradeTimeInLong"`
-	NetChange              float64              `json:"netChange"`
+	NetChange              Money                `json:"netChange"`
 	Volatility             float64              `json:"volatility"`
 	Delta                  float64              `json:"delta"`
 	Gamma                  float64              `json:"gamma"`
 	Theta                  float64              `json:"theta"`
 	Vega                   float64              `json:"vega"`
 	Rho                    float64              `json:"rho"`
-	TimeValue              float64              `json:"timeValue"`
+	TimeValue              Money                `json:"timeValue"`
 	OpenInterest           float64              `json:"openInterest"`
 	IsInTheMoney           bool                 `json:"isInTheMoney"`
-	TheoreticalOptionValue float64              `json:"theoreticalOptionValue"`
+	TheoreticalOptionValue Money                `json:"theoreticalOptionValue"`
 	TheoreticalVolatility  float64              `json:"theoreticalVolatility"`
 	IsMini                 bool                 `json:"isMini"`
 	IsNonStandard          bool                 `json:"isNonStandard"`
@@ -93,7 +95,7 @@ type Option struct {
 	DeliverableNote        string               `json:"deliverableNote"`
 	IsIndexOption          bool                 `json:"isIndexOption"`
 	PercentChange          float64              `json:"percentChange"`
-	MarkChange             float64              `json:"markChange"`
+	MarkChange             Money                `json:"markChange"`
 	MarkPercentChange      float64              `json:"markPercentChange"`
 }
 
@@ -103,6 +105,10 @@ func (s StrikePrice) String() string {
 	return fmt.Sprintf("%.1f", s)
 }
 
+func (s StrikePrice) Money() Money {
+	return Dollars(float64(s))
+}
+
 func (v *StrikePrice) UnmarshalText(b []byte) error {
 	f, err := strconv.ParseFloat(string(b), 64)
 	if err != nil {
@@ -158,6 +164,21 @@ func (s Strike) DeltaBetween(a, b float64) bool {
 	return s.DeltaAbove(a) && s.DeltaBelow(b)
 }
 
+// PutSymbol is the symbol of the strike's put, or "" if the chain had none
+func (s Strike) PutSymbol() string {
+	if len(s.Put) == 0 {
+		return ""
+	}
+	return s.Put[0].Symbol
+}
+
+func (s Strike) CallSymbol() string {
+	if len(s.Call) == 0 {
+		return ""
+	}
+	return s.Call[0].Symbol
+}
+
 type StrikeMap map[StrikePrice][]Option
 
 type byPrice []Strike
@@ -215,6 +236,13 @@ func (e ExpirationDate) DTE() int {
 	return int(dte)
 }
 
+// TradingDTE counts trading days from now to expiration, where DTE counts
+// calendar days as of when the chain was fetched
+func (e ExpirationDate) TradingDTE(now time.Time) int {
+	d := e.Date()
+	return calendar.DTE(now, calendar.Date(d.Year(), d.Month(), d.Day()))
+}
+
 func (ch *OptionChain) NearestDTE(target int) ExpirationDate {
 	dates := ch.ExpirationDates()
 	if len(dates) == 0 {
@@ -237,24 +265,24 @@ func abs(x int) int {
 }
 
 type Underlying struct {
-	Ask               float64 `json:"ask"`
+	Ask               Money   `json:"ask"`
 	AskSize           int     `json:"askSize"`
-	Bid               float64 `json:"bid"`
+	Bid               Money   `json:"bid"`
 	BidSize           float64 `json:"bidSize"`
-	Change            float64 `json:"change"`
-	Close             float64 `json:"close"`
+	Change            Money   `json:"change"`
+	Close             Money   `json:"close"`
 	Delayed           bool    `json:"delayed"`
 	Description       string  `json:"description"`
 	ExchangeName      string  `json:"exchangeName"`
-	FiftyTwoWeekHigh  float64 `json:"fiftyTwoWeekHigh"`
-	FiftyTwoWeekLow   float64 `json:"fiftyTwoWeekLow"`
-	HighPrice         float64 `json:"highPrice"`
-	Last              float64 `json:"last"`
-	LowPrice          float64 `json:"lowPrice"`
-	Mark              float64 `json:"mark"`
-	MarkChange        float64 `json:"markChange"`
+	FiftyTwoWeekHigh  Money   `json:"fiftyTwoWeekHigh"`
+	FiftyTwoWeekLow   Money   `json:"fiftyTwoWeekLow"`
+	HighPrice         Money   `json:"highPrice"`
+	Last              Money   `json:"last"`
+	LowPrice          Money   `json:"lowPrice"`
+	Mark              Money   `json:"mark"`
+	MarkChange        Money   `json:"markChange"`
 	MarkPercentChange float64 `json:"markPercentChange"`
-	OpenPrice         float64 `json:"openPrice"`
+	OpenPrice         Money   `json:"openPrice"`
 	PercentChange     float64 `json:"percentChange"`
 	QuoteTime         float64 `json:"quoteTime"`
 	Symbol            string  `json:"symbol"`
@@ -1,6 +1,136 @@
 package tdam
 
-func (account *Account) GetOrders() ([]Order, error) {
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+)
 
-	return nil, nil
+// OrderFilter narrows GetOrders.  Zero fields are not sent.
+type OrderFilter struct {
+	MaxResults      int
+	FromEnteredTime time.Time // TD allows at most 60 days back
+	ToEnteredTime   time.Time
+	Status          OrderStatus
+}
+
+func (f OrderFilter) query() url.Values {
+	query := url.Values{}
+	if f.MaxResults > 0 {
+		query.Set("maxResults", strconv.Itoa(f.MaxResults))
+	}
+	if !f.FromEnteredTime.IsZero() {
+		query.Set("fromEnteredTime", f.FromEnteredTime.Format("2006-01-02"))
+	}
+	if !f.ToEnteredTime.IsZero() {
+		query.Set("toEnteredTime", f.ToEnteredTime.Format("2006-01-02"))
+	}
+	if f.Status != "" {
+		query.Set("status", string(f.Status))
+	}
+	return query
+}
+
+func (a *Account) ordersEndpoint() string {
+	return fmt.Sprintf("/v1/accounts/%s/orders", a.AccountId)
+}
+
+func (a *Account) GetOrders(filter OrderFilter) ([]Order, error) {
+	return a.GetOrdersContext(context.Background(), filter)
+}
+
+func (a *Account) GetOrdersContext(ctx context.Context, filter OrderFilter) ([]Order, error) {
+	var orders []Order
+	if err := a.DoJSONContext(ctx, Request{
+		Endpoint:      a.ordersEndpoint(),
+		Query:         filter.query(),
+		Authenticated: true,
+	}, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (a *Account) GetOrder(orderID int) (*Order, error) {
+	return a.GetOrderContext(context.Background(), orderID)
+}
+
+func (a *Account) GetOrderContext(ctx context.Context, orderID int) (*Order, error) {
+	var order Order
+	if err := a.DoJSONContext(ctx, Request{
+		Endpoint:      fmt.Sprintf("%s/%d", a.ordersEndpoint(), orderID),
+		Authenticated: true,
+	}, &order); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// PlaceOrder submits order and returns the new order's id
+func (a *Account) PlaceOrder(order Order) (int, error) {
+	return a.PlaceOrderContext(context.Background(), order)
+}
+
+func (a *Account) PlaceOrderContext(ctx context.Context, order Order) (int, error) {
+	return a.submitOrder(ctx, "POST", a.ordersEndpoint(), order)
+}
+
+// ReplaceOrder cancels orderID and places order in its place,
+// returning the id of the replacement
+func (a *Account) ReplaceOrder(orderID int, order Order) (int, error) {
+	return a.ReplaceOrderContext(context.Background(), orderID, order)
+}
+
+func (a *Account) ReplaceOrderContext(ctx context.Context, orderID int, order Order) (int, error) {
+	return a.submitOrder(ctx, "PUT", fmt.Sprintf("%s/%d", a.ordersEndpoint(), orderID), order)
+}
+
+func (a *Account) CancelOrder(orderID int) error {
+	return a.CancelOrderContext(context.Background(), orderID)
+}
+
+func (a *Account) CancelOrderContext(ctx context.Context, orderID int) error {
+	return a.DoJSONContext(ctx, Request{
+		Method:        "DELETE",
+		Endpoint:      fmt.Sprintf("%s/%d", a.ordersEndpoint(), orderID),
+		Authenticated: true,
+	}, nil)
+}
+
+func (a *Account) submitOrder(ctx context.Context, method, endpoint string, order Order) (int, error) {
+	resp, err := a.DoContext(ctx, Request{
+		Method:        method,
+		Endpoint:      endpoint,
+		Body:          order,
+		Authenticated: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if err := CheckResponse(resp); err != nil {
+		return 0, err
+	}
+	return orderIDFromLocation(resp)
+}
+
+// TD doesn't return the order, only a Location header ending in its id
+func orderIDFromLocation(resp *http.Response) (int, error) {
+	loc := resp.Header.Get("Location")
+	if loc == "" {
+		return 0, fmt.Errorf("no Location header in order response")
+	}
+	u, err := url.Parse(loc)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.Atoi(path.Base(u.Path))
+	if err != nil {
+		return 0, fmt.Errorf("can't parse order id from %q", loc)
+	}
+	return id, nil
 }
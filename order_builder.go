@@ -0,0 +1,390 @@
+package tdam
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OrderBuilder builds an Order a leg at a time:
+//
+//	order, err := tdam.Equity("AAPL").Buy(100).Limit(150.25).GoodTillCancel().Build()
+//	order, err := tdam.OptionLeg("SPY_121820P300").SellToOpen(1).Limit(1.05).Build()
+//
+// Mistakes are collected and returned from Build rather than panicking
+// part way through the chain.
+type OrderBuilder struct {
+	order      Order
+	instrument OrderInstrument // instrument for the next leg
+	errs       []string
+}
+
+// Equity starts an order whose first leg is the equity symbol
+func Equity(symbol string) *OrderBuilder {
+	return (&OrderBuilder{}).Equity(symbol)
+}
+
+// OptionLeg starts an order whose first leg is the option symbol, eg SPY_121820P300
+func OptionLeg(symbol string) *OrderBuilder {
+	return (&OrderBuilder{}).Option(symbol)
+}
+
+// Equity switches the instrument for the legs that follow
+func (b *OrderBuilder) Equity(symbol string) *OrderBuilder {
+	b.instrument = &EquityInstrument{InstrumentInfo{AssetType: EQUITY, Symbol: symbol}}
+	return b
+}
+
+// Option switches the instrument for the legs that follow
+func (b *OrderBuilder) Option(symbol string) *OrderBuilder {
+	b.instrument = &OptionInstrument{InstrumentInfo: InstrumentInfo{AssetType: OPTION, Symbol: symbol}}
+	return b
+}
+
+func (b *OrderBuilder) errorf(format string, args ...interface{}) *OrderBuilder {
+	b.errs = append(b.errs, fmt.Sprintf(format, args...))
+	return b
+}
+
+// Leg adds a leg for the current instrument
+func (b *OrderBuilder) Leg(instruction Instruction, quantity int) *OrderBuilder {
+	return b.leg(instruction, float64(quantity))
+}
+
+func (b *OrderBuilder) leg(instruction Instruction, quantity float64) *OrderBuilder {
+	if b.instrument == nil {
+		return b.errorf("%s leg added before choosing an instrument", instruction)
+	}
+	b.order.OrderLegCollection = append(b.order.OrderLegCollection, OrderLegCollection{
+		OrderLegType: b.instrument.Info().AssetType,
+		LegId:        len(b.order.OrderLegCollection) + 1,
+		Instrument:   b.instrument,
+		Instruction:  instruction,
+		Quantity:     quantity,
+	})
+	// a single leg order's quantity is its leg's, multi-leg orders are
+	// in units of the ratio between their legs
+	if b.order.Quantity == 0 {
+		b.order.Quantity = quantity
+	}
+	return b
+}
+
+func (b *OrderBuilder) Buy(quantity int) *OrderBuilder {
+	return b.Leg(InstructionBuy, quantity)
+}
+func (b *OrderBuilder) Sell(quantity int) *OrderBuilder {
+	return b.Leg(InstructionSell, quantity)
+}
+func (b *OrderBuilder) SellShort(quantity int) *OrderBuilder {
+	return b.Leg(InstructionSellShort, quantity)
+}
+func (b *OrderBuilder) BuyToCover(quantity int) *OrderBuilder {
+	return b.Leg(InstructionBuyToCover, quantity)
+}
+func (b *OrderBuilder) BuyToOpen(quantity int) *OrderBuilder {
+	return b.Leg(InstructionBuyToOpen, quantity)
+}
+func (b *OrderBuilder) BuyToClose(quantity int) *OrderBuilder {
+	return b.Leg(InstructionBuyToClose, quantity)
+}
+func (b *OrderBuilder) SellToOpen(quantity int) *OrderBuilder {
+	return b.Leg(InstructionSellToOpen, quantity)
+}
+func (b *OrderBuilder) SellToClose(quantity int) *OrderBuilder {
+	return b.Leg(InstructionSellToClose, quantity)
+}
+
+// Quantity overrides the order quantity, which otherwise comes from the first leg
+func (b *OrderBuilder) Quantity(quantity int) *OrderBuilder {
+	b.order.Quantity = float64(quantity)
+	return b
+}
+
+func (b *OrderBuilder) Market() *OrderBuilder {
+	b.order.OrderType = OrderTypeMarket
+	return b
+}
+
+func (b *OrderBuilder) Limit(price float64) *OrderBuilder {
+	b.order.OrderType = OrderTypeLimit
+	b.order.Price = Dollars(price)
+	return b
+}
+
+func (b *OrderBuilder) Stop(stopPrice float64) *OrderBuilder {
+	b.order.OrderType = OrderTypeStop
+	b.order.StopPrice = Dollars(stopPrice)
+	return b
+}
+
+func (b *OrderBuilder) StopLimit(stopPrice, limitPrice float64) *OrderBuilder {
+	b.order.OrderType = OrderTypeStopLimit
+	b.order.StopPrice = Dollars(stopPrice)
+	b.order.Price = Dollars(limitPrice)
+	return b
+}
+
+// TrailingStop trails the last price by offset dollars
+func (b *OrderBuilder) TrailingStop(offset float64) *OrderBuilder {
+	return b.trailingStop(offset, LinkTypeValue)
+}
+
+// TrailingStopPercent trails the last price by percent
+func (b *OrderBuilder) TrailingStopPercent(percent float64) *OrderBuilder {
+	return b.trailingStop(percent, LinkTypePercent)
+}
+
+func (b *OrderBuilder) trailingStop(offset float64, linkType LinkType) *OrderBuilder {
+	b.order.OrderType = OrderTypeTrailingStop
+	b.order.StopPriceLinkBasis = LinkBasisLast
+	b.order.StopPriceLinkType = linkType
+	b.order.StopPriceOffset = offset
+	return b
+}
+
+func (b *OrderBuilder) MarketOnClose() *OrderBuilder {
+	b.order.OrderType = OrderTypeMarketOnClose
+	return b
+}
+
+// NetDebit prices a multi-leg order at the net amount paid per unit
+func (b *OrderBuilder) NetDebit(price float64) *OrderBuilder {
+	b.order.OrderType = OrderTypeNetDebit
+	b.order.Price = Dollars(price)
+	return b
+}
+
+// NetCredit prices a multi-leg order at the net amount received per unit
+func (b *OrderBuilder) NetCredit(price float64) *OrderBuilder {
+	b.order.OrderType = OrderTypeNetCredit
+	b.order.Price = Dollars(price)
+	return b
+}
+
+func (b *OrderBuilder) NetZero() *OrderBuilder {
+	b.order.OrderType = OrderTypeNetZero
+	return b
+}
+
+func (b *OrderBuilder) Duration(d Duration) *OrderBuilder {
+	b.order.Duration = d
+	return b
+}
+
+func (b *OrderBuilder) Day() *OrderBuilder            { return b.Duration(DurationDay) }
+func (b *OrderBuilder) GoodTillCancel() *OrderBuilder { return b.Duration(DurationGoodTillCancel) }
+func (b *OrderBuilder) FillOrKill() *OrderBuilder     { return b.Duration(DurationFillOrKill) }
+
+func (b *OrderBuilder) Session(s Session) *OrderBuilder {
+	b.order.Session = s
+	return b
+}
+
+// Seamless works the order in the pre-market, regular and post-market sessions
+func (b *OrderBuilder) Seamless() *OrderBuilder { return b.Session(SessionSeamless) }
+
+func (b *OrderBuilder) AllOrNone() *OrderBuilder {
+	b.order.SpecialInstruction = AllOrNone
+	return b
+}
+
+func (b *OrderBuilder) Strategy(s ComplexOrderStrategyType) *OrderBuilder {
+	b.order.ComplexOrderStrategyType = s
+	return b
+}
+
+func (b *OrderBuilder) Tag(tag string) *OrderBuilder {
+	b.order.Tag = tag
+	return b
+}
+
+// Build fills in defaults (a NORMAL session DAY order) and validates the result
+func (b *OrderBuilder) Build() (Order, error) {
+	order := b.order
+	order.OrderLegCollection = append([]OrderLegCollection(nil), b.order.OrderLegCollection...)
+	if order.Session == "" {
+		order.Session = SessionNormal
+	}
+	if order.Duration == "" {
+		order.Duration = DurationDay
+	}
+	if order.OrderStrategyType == "" {
+		order.OrderStrategyType = StrategySingle
+	}
+	if order.OrderType == "" {
+		order.OrderType = OrderTypeMarket
+	}
+
+	errs := append([]string(nil), b.errs...)
+	if err := order.Validate(); err != nil {
+		errs = append(errs, err.(*ValidationError).Errors...)
+	}
+	if len(errs) > 0 {
+		return order, &ValidationError{Errors: errs}
+	}
+	return order, nil
+}
+
+// ValidationError lists everything wrong with an order
+type ValidationError struct {
+	Errors []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid order: " + strings.Join(e.Errors, "; ")
+}
+
+var equityInstructions = map[Instruction]bool{
+	InstructionBuy:        true,
+	InstructionSell:       true,
+	InstructionSellShort:  true,
+	InstructionBuyToCover: true,
+}
+
+var optionInstructions = map[Instruction]bool{
+	InstructionBuyToOpen:   true,
+	InstructionBuyToClose:  true,
+	InstructionSellToOpen:  true,
+	InstructionSellToClose: true,
+}
+
+// Validate checks for combinations TD would reject, returning a *ValidationError
+func (o Order) Validate() error {
+	if errs := o.validate(); len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+func (o Order) validate() []string {
+	var errs []string
+	switch o.OrderStrategyType {
+	case StrategyOCO:
+		// the OCO itself is just a container for the orders it cancels between
+		if len(o.OrderLegCollection) > 0 {
+			errs = append(errs, "OCO orders can't have legs of their own")
+		}
+		if len(o.ChildOrderStrategies) < 2 {
+			errs = append(errs, "OCO orders need at least two child orders")
+		}
+	case StrategyTrigger:
+		errs = o.validateSingle()
+		if len(o.ChildOrderStrategies) == 0 {
+			errs = append(errs, "TRIGGER orders need a child order to trigger")
+		}
+	default:
+		errs = o.validateSingle()
+		if len(o.ChildOrderStrategies) > 0 {
+			errs = append(errs, fmt.Sprintf("%s orders can't have child orders", o.OrderStrategyType))
+		}
+	}
+
+	for i, child := range o.ChildOrderStrategies {
+		for _, e := range child.validate() {
+			errs = append(errs, fmt.Sprintf("child order %d: %s", i+1, e))
+		}
+	}
+	return errs
+}
+
+func (o Order) validateSingle() []string {
+	var errs []string
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf(format, args...))
+	}
+
+	if len(o.OrderLegCollection) == 0 {
+		add("order has no legs")
+	}
+	if o.Quantity <= 0 {
+		add("quantity must be positive")
+	}
+
+	hasOption := false
+	for _, leg := range o.OrderLegCollection {
+		if leg.Quantity <= 0 {
+			add("leg %d quantity must be positive", leg.LegId)
+		}
+		if leg.Instrument == nil {
+			add("leg %d has no instrument", leg.LegId)
+			continue
+		}
+		inst := leg.Instrument.Info()
+		if inst.Symbol == "" {
+			add("leg %d has no symbol", leg.LegId)
+		}
+		switch inst.AssetType {
+		case EQUITY:
+			if !equityInstructions[leg.Instruction] {
+				add("%s isn't a valid instruction for equity %s", leg.Instruction, inst.Symbol)
+			}
+		case OPTION:
+			hasOption = true
+			if !optionInstructions[leg.Instruction] {
+				add("%s isn't a valid instruction for option %s", leg.Instruction, inst.Symbol)
+			}
+		}
+	}
+
+	switch o.OrderType {
+	case OrderTypeMarket, OrderTypeMarketOnClose:
+		if o.Price != 0 || o.StopPrice != 0 {
+			add("%s orders can't have a price", o.OrderType)
+		}
+	case OrderTypeLimit:
+		if o.Price <= 0 {
+			add("LIMIT orders need a price")
+		}
+		if o.StopPrice != 0 {
+			add("LIMIT orders can't have a stop price")
+		}
+	case OrderTypeStop:
+		if o.StopPrice <= 0 {
+			add("STOP orders need a stop price")
+		}
+		if o.Price != 0 {
+			add("STOP orders can't have a limit price, use STOP_LIMIT")
+		}
+	case OrderTypeStopLimit:
+		if o.StopPrice <= 0 {
+			add("STOP_LIMIT orders need a stop price")
+		}
+		if o.Price <= 0 {
+			add("STOP_LIMIT orders need a limit price")
+		}
+	case OrderTypeTrailingStop:
+		if o.StopPriceOffset <= 0 {
+			add("TRAILING_STOP orders need a stop price offset")
+		}
+	case OrderTypeNetDebit, OrderTypeNetCredit:
+		if o.Price <= 0 {
+			add("%s orders need a price", o.OrderType)
+		}
+		fallthrough
+	case OrderTypeNetZero:
+		if len(o.OrderLegCollection) < 2 {
+			add("%s orders need more than one leg", o.OrderType)
+		}
+	}
+
+	switch o.OrderType {
+	case OrderTypeStop, OrderTypeStopLimit, OrderTypeTrailingStop, OrderTypeTrailingStopLimit:
+		if len(o.OrderLegCollection) > 1 {
+			add("%s orders can only have one leg, TD doesn't take stops on spreads", o.OrderType)
+		}
+	}
+
+	if o.OrderType == OrderTypeMarket && o.Duration != "" && o.Duration != DurationDay {
+		add("MARKET orders can only be DAY orders")
+	}
+	if o.Session != "" && o.Session != SessionNormal {
+		if o.OrderType != OrderTypeLimit {
+			add("%s session orders must be LIMIT orders", o.Session)
+		}
+		if hasOption {
+			add("options only trade in the NORMAL session")
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,58 @@
+package tdam
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestOrderBuilder(t *testing.T) {
+	order, err := Equity("AAPL").Buy(100).Limit(150.25).GoodTillCancel().Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"session":"NORMAL","duration":"GOOD_TILL_CANCEL","orderType":"LIMIT","quantity":100,"price":150.25,` +
+		`"orderLegCollection":[{"orderLegType":"EQUITY","legId":1,"instrument":{"assetType":"EQUITY","symbol":"AAPL"},"instruction":"BUY","quantity":100}],` +
+		`"orderStrategyType":"SINGLE"}`
+	if string(b) != want {
+		t.Errorf("got  %s\nwant %s", b, want)
+	}
+
+	order, err = OptionLeg("SPY_121820P300").BuyToOpen(1).
+		Option("SPY_121820P290").SellToOpen(1).NetDebit(1.25).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(order.OrderLegCollection) != 2 || order.Quantity != 1 || order.OrderLegCollection[1].LegId != 2 {
+		t.Errorf("unexpected spread: %+v", order)
+	}
+}
+
+func TestOrderBuilderRejects(t *testing.T) {
+	for name, tc := range map[string]struct {
+		b    *OrderBuilder
+		want string
+	}{
+		"stop without price":      {Equity("AAPL").Sell(10).Stop(0), "need a stop price"},
+		"stop limit no limit":     {Equity("AAPL").Sell(10).StopLimit(100, 0), "need a limit price"},
+		"limit without price":     {Equity("AAPL").Buy(10).Limit(0), "need a price"},
+		"gtc market":              {Equity("AAPL").Buy(10).Market().GoodTillCancel(), "only be DAY"},
+		"option instruction":      {Equity("AAPL").BuyToOpen(10), "BUY_TO_OPEN isn't a valid instruction"},
+		"equity instruction":      {OptionLeg("SPY_121820P300").Buy(1).Limit(1), "BUY isn't a valid instruction"},
+		"extended market":         {Equity("AAPL").Buy(10).Seamless(), "must be LIMIT"},
+		"extended option":         {OptionLeg("SPY_121820P300").BuyToOpen(1).Limit(1).Seamless(), "NORMAL session"},
+		"single leg net":          {OptionLeg("SPY_121820P300").BuyToOpen(1).NetDebit(1), "more than one leg"},
+		"no legs":                 {&OrderBuilder{}, "no legs"},
+		"zero quantity":           {Equity("AAPL").Buy(0), "quantity must be positive"},
+		"trailing without offset": {Equity("AAPL").Sell(10).TrailingStop(0), "offset"},
+	} {
+		_, err := tc.b.Build()
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", name, tc.want, err)
+		}
+	}
+}
@@ -1,156 +1,339 @@
 package tdam
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type Session string
+type Duration string
+type OrderType string
+type ComplexOrderStrategyType string
+type LinkBasis string
+type LinkType string
+type StopType string
+type TaxLotMethod string
+type SpecialInstruction string
+type OrderStrategyType string
+type OrderStatus string
+type Instruction string
+type PositionEffect string
+type QuantityType string
+type ActivityType string
+type ExecutionType string
+
+const (
+	SessionNormal   Session = "NORMAL"
+	SessionAM       Session = "AM"
+	SessionPM       Session = "PM"
+	SessionSeamless Session = "SEAMLESS"
+
+	DurationDay            Duration = "DAY"
+	DurationGoodTillCancel Duration = "GOOD_TILL_CANCEL"
+	DurationFillOrKill     Duration = "FILL_OR_KILL"
+
+	OrderTypeMarket            OrderType = "MARKET"
+	OrderTypeLimit             OrderType = "LIMIT"
+	OrderTypeStop              OrderType = "STOP"
+	OrderTypeStopLimit         OrderType = "STOP_LIMIT"
+	OrderTypeTrailingStop      OrderType = "TRAILING_STOP"
+	OrderTypeMarketOnClose     OrderType = "MARKET_ON_CLOSE"
+	OrderTypeExercise          OrderType = "EXERCISE"
+	OrderTypeTrailingStopLimit OrderType = "TRAILING_STOP_LIMIT"
+	OrderTypeNetDebit          OrderType = "NET_DEBIT"
+	OrderTypeNetCredit         OrderType = "NET_CREDIT"
+	OrderTypeNetZero           OrderType = "NET_ZERO"
+
+	ComplexNone                   ComplexOrderStrategyType = "NONE"
+	ComplexCovered                ComplexOrderStrategyType = "COVERED"
+	ComplexVertical               ComplexOrderStrategyType = "VERTICAL"
+	ComplexBackRatio              ComplexOrderStrategyType = "BACK_RATIO"
+	ComplexCalendar               ComplexOrderStrategyType = "CALENDAR"
+	ComplexDiagonal               ComplexOrderStrategyType = "DIAGONAL"
+	ComplexStraddle               ComplexOrderStrategyType = "STRADDLE"
+	ComplexStrangle               ComplexOrderStrategyType = "STRANGLE"
+	ComplexCollarSynthetic        ComplexOrderStrategyType = "COLLAR_SYNTHETIC"
+	ComplexButterfly              ComplexOrderStrategyType = "BUTTERFLY"
+	ComplexCondor                 ComplexOrderStrategyType = "CONDOR"
+	ComplexIronCondor             ComplexOrderStrategyType = "IRON_CONDOR"
+	ComplexVerticalRoll           ComplexOrderStrategyType = "VERTICAL_ROLL"
+	ComplexCollarWithStock        ComplexOrderStrategyType = "COLLAR_WITH_STOCK"
+	ComplexDoubleDiagonal         ComplexOrderStrategyType = "DOUBLE_DIAGONAL"
+	ComplexUnbalancedButterfly    ComplexOrderStrategyType = "UNBALANCED_BUTTERFLY"
+	ComplexUnbalancedCondor       ComplexOrderStrategyType = "UNBALANCED_CONDOR"
+	ComplexUnbalancedIronCondor   ComplexOrderStrategyType = "UNBALANCED_IRON_CONDOR"
+	ComplexUnbalancedVerticalRoll ComplexOrderStrategyType = "UNBALANCED_VERTICAL_ROLL"
+	ComplexCustom                 ComplexOrderStrategyType = "CUSTOM"
+
+	LinkBasisManual  LinkBasis = "MANUAL"
+	LinkBasisBase    LinkBasis = "BASE"
+	LinkBasisTrigger LinkBasis = "TRIGGER"
+	LinkBasisLast    LinkBasis = "LAST"
+	LinkBasisBid     LinkBasis = "BID"
+	LinkBasisAsk     LinkBasis = "ASK"
+	LinkBasisAskBid  LinkBasis = "ASK_BID"
+	LinkBasisMark    LinkBasis = "MARK"
+	LinkBasisAverage LinkBasis = "AVERAGE"
+
+	LinkTypeValue   LinkType = "VALUE"
+	LinkTypePercent LinkType = "PERCENT"
+	LinkTypeTick    LinkType = "TICK"
+
+	StopTypeStandard StopType = "STANDARD"
+	StopTypeBid      StopType = "BID"
+	StopTypeAsk      StopType = "ASK"
+	StopTypeLast     StopType = "LAST"
+	StopTypeMark     StopType = "MARK"
+
+	TaxLotFIFO        TaxLotMethod = "FIFO"
+	TaxLotLIFO        TaxLotMethod = "LIFO"
+	TaxLotHighCost    TaxLotMethod = "HIGH_COST"
+	TaxLotLowCost     TaxLotMethod = "LOW_COST"
+	TaxLotAverageCost TaxLotMethod = "AVERAGE_COST"
+	TaxLotSpecificLot TaxLotMethod = "SPECIFIC_LOT"
+
+	AllOrNone            SpecialInstruction = "ALL_OR_NONE"
+	DoNotReduce          SpecialInstruction = "DO_NOT_REDUCE"
+	AllOrNoneDoNotReduce SpecialInstruction = "ALL_OR_NONE_DO_NOT_REDUCE"
+
+	StrategySingle  OrderStrategyType = "SINGLE"
+	StrategyOCO     OrderStrategyType = "OCO"
+	StrategyTrigger OrderStrategyType = "TRIGGER"
+
+	StatusAwaitingParentOrder  OrderStatus = "AWAITING_PARENT_ORDER"
+	StatusAwaitingCondition    OrderStatus = "AWAITING_CONDITION"
+	StatusAwaitingManualReview OrderStatus = "AWAITING_MANUAL_REVIEW"
+	StatusAccepted             OrderStatus = "ACCEPTED"
+	StatusAwaitingUROut        OrderStatus = "AWAITING_UR_OUT"
+	StatusPendingActivation    OrderStatus = "PENDING_ACTIVATION"
+	StatusQueued               OrderStatus = "QUEUED"
+	StatusWorking              OrderStatus = "WORKING"
+	StatusRejected             OrderStatus = "REJECTED"
+	StatusPendingCancel        OrderStatus = "PENDING_CANCEL"
+	StatusCanceled             OrderStatus = "CANCELED"
+	StatusPendingReplace       OrderStatus = "PENDING_REPLACE"
+	StatusReplaced             OrderStatus = "REPLACED"
+	StatusFilled               OrderStatus = "FILLED"
+	StatusExpired              OrderStatus = "EXPIRED"
+
+	InstructionBuy         Instruction = "BUY"
+	InstructionSell        Instruction = "SELL"
+	InstructionBuyToCover  Instruction = "BUY_TO_COVER"
+	InstructionSellShort   Instruction = "SELL_SHORT"
+	InstructionBuyToOpen   Instruction = "BUY_TO_OPEN"
+	InstructionBuyToClose  Instruction = "BUY_TO_CLOSE"
+	InstructionSellToOpen  Instruction = "SELL_TO_OPEN"
+	InstructionSellToClose Instruction = "SELL_TO_CLOSE"
+	InstructionExchange    Instruction = "EXCHANGE"
+
+	PositionOpening   PositionEffect = "OPENING"
+	PositionClosing   PositionEffect = "CLOSING"
+	PositionAutomatic PositionEffect = "AUTOMATIC"
+
+	QuantityAllShares QuantityType = "ALL_SHARES"
+	QuantityDollars   QuantityType = "DOLLARS"
+	QuantityShares    QuantityType = "SHARES"
+
+	ActivityExecution   ActivityType = "EXECUTION"
+	ActivityOrderAction ActivityType = "ORDER_ACTION"
+
+	ExecutionFill ExecutionType = "FILL"
+)
+
 type Order struct {
-	Session                  string               `json:"session"`   // 'NORMAL' or 'AM' or 'PM' or 'SEAMLESS'
-	Duration                 string               `json:"duration"`  //: "'DAY' or 'GOOD_TILL_CANCEL' or 'FILL_OR_KILL'",
-	OrderType                string               `json:"orderType"` //: "'MARKET' or 'LIMIT' or 'STOP' or 'STOP_LIMIT' or 'TRAILING_STOP' or 'MARKET_ON_CLOSE' or 'EXERCISE' or 'TRAILING_STOP_LIMIT' or 'NET_DEBIT' or 'NET_CREDIT' or 'NET_ZERO'",
-	CancelTime               interface{}          `json:"cancelTime"`
-	ComplexOrderStrategyType string               `json:"complexOrderStrategyType"` // 'NONE' or 'COVERED' or 'VERTICAL' or 'BACK_RATIO' or 'CALENDAR' or 'DIAGONAL' or 'STRADDLE' or 'STRANGLE' or 'COLLAR_SYNTHETIC' or 'BUTTERFLY' or 'CONDOR' or 'IRON_CONDOR' or 'VERTICAL_ROLL' or 'COLLAR_WITH_STOCK' or 'DOUBLE_DIAGONAL' or 'UNBALANCED_BUTTERFLY' or 'UNBALANCED_CONDOR' or 'UNBALANCED_IRON_CONDOR' or 'UNBALANCED_VERTICAL_ROLL' or 'CUSTOM'
-	Quantity                 int                  `json:"quantity"`
-	FilledQuantity           int                  `json:"filledQuantity"`
-	RemainingQuantity        int                  `json:"remainingQuantity"`
-	RequestedDestination     string               `json:"requestedDestination"` // 'INET' or 'ECN_ARCA' or 'CBOE' or 'AMEX' or 'PHLX' or 'ISE' or 'BOX' or 'NYSE' or 'NASDAQ' or 'BATS' or 'C2' or 'AUTO'
-	DestinationLinkName      string               `json:"destinationLinkName"`  // string
-	ReleaseTime              string               `json:"releaseTime"`          // string
-	StopPrice                int                  `json:"stopPrice"`
-	StopPriceLinkBasis       string               `json:"stopPriceLinkBasis"` // 'MANUAL' or 'BASE' or 'TRIGGER' or 'LAST' or 'BID' or 'ASK' or 'ASK_BID' or 'MARK' or 'AVERAGE'
-	StopPriceLinkType        string               `json:"stopPriceLinkType"`  // 'VALUE' or 'PERCENT' or 'TICK'
-	StopPriceOffset          int                  `json:"stopPriceOffset"`
-	StopType                 string               `json:"stopType"`       // 'STANDARD' or 'BID' or 'ASK' or 'LAST' or 'MARK'
-	PriceLinkBasis           string               `json:"priceLinkBasis"` // 'MANUAL' or 'BASE' or 'TRIGGER' or 'LAST' or 'BID' or 'ASK' or 'ASK_BID' or 'MARK' or 'AVERAGE'
-	PriceLinkType            string               `json:"priceLinkType"`  // 'VALUE' or 'PERCENT' or 'TICK'
-	Price                    int                  `json:"price"`
-	TaxLotMethod             string               `json:"taxLotMethod"` // 'FIFO' or 'LIFO' or 'HIGH_COST' or 'LOW_COST' or 'AVERAGE_COST' or 'SPECIFIC_LOT'
-	OrderLegCollection       []OrderLegCollection `json:"orderLegCollection"`
-	ActivationPrice          int                  `json:"activationPrice"`
-	SpecialInstruction       string               `json:"specialInstruction"` // 'ALL_OR_NONE' or 'DO_NOT_REDUCE' or 'ALL_OR_NONE_DO_NOT_REDUCE'
-	OrderStrategyType        string               `json:"orderStrategyType"`  // 'SINGLE' or 'OCO' or 'TRIGGER'
-	OrderId                  int                  `json:"orderId"`
-	Cancelable               bool                 `json:"cancelable"`
-	Editable                 bool                 `json:"editable"`
-	Status                   string               `json:"status"`      // 'AWAITING_PARENT_ORDER' or 'AWAITING_CONDITION' or 'AWAITING_MANUAL_REVIEW' or 'ACCEPTED' or 'AWAITING_UR_OUT' or 'PENDING_ACTIVATION' or 'QUEUED' or 'WORKING' or 'REJECTED' or 'PENDING_CANCEL' or 'CANCELED' or 'PENDING_REPLACE' or 'REPLACED' or 'FILLED' or 'EXPIRED'
-	EnteredTime              string               `json:"enteredTime"` // string
-	CloseTime                string               `json:"closeTime"`   // string
-	Tag                      string               `json:"tag"`         // string
-	AccountId                int                  `json:"accountId"`
-	/*
-	  "orderActivityCollection": [
-	    "The type <OrderActivity> has the following subclasses [Execution] descriptions are listed below"
-	  ],
-	  "replacingOrderCollection": [
-	    {}
-	  ],
-	  "childOrderStrategies": [
-	    {}
-	  ],
-	*/
-	StatusDescription string `json:"statusDescription"` // string
+	Session                  Session                  `json:"session,omitempty"`
+	Duration                 Duration                 `json:"duration,omitempty"`
+	OrderType                OrderType                `json:"orderType,omitempty"`
+	CancelTime               *CancelTime              `json:"cancelTime,omitempty"`
+	ComplexOrderStrategyType ComplexOrderStrategyType `json:"complexOrderStrategyType,omitempty"`
+	Quantity                 float64                  `json:"quantity,omitempty"`
+	FilledQuantity           float64                  `json:"filledQuantity,omitempty"`
+	RemainingQuantity        float64                  `json:"remainingQuantity,omitempty"`
+	RequestedDestination     string                   `json:"requestedDestination,omitempty"` // 'INET' or 'ECN_ARCA' or 'CBOE' or 'AMEX' or 'PHLX' or 'ISE' or 'BOX' or 'NYSE' or 'NASDAQ' or 'BATS' or 'C2' or 'AUTO'
+	DestinationLinkName      string                   `json:"destinationLinkName,omitempty"`
+	ReleaseTime              *TDTime                  `json:"releaseTime,omitempty"`
+	StopPrice                Money                    `json:"stopPrice,omitempty"`
+	StopPriceLinkBasis       LinkBasis                `json:"stopPriceLinkBasis,omitempty"`
+	StopPriceLinkType        LinkType                 `json:"stopPriceLinkType,omitempty"`
+	StopPriceOffset          float64                  `json:"stopPriceOffset,omitempty"`
+	StopType                 StopType                 `json:"stopType,omitempty"`
+	PriceLinkBasis           LinkBasis                `json:"priceLinkBasis,omitempty"`
+	PriceLinkType            LinkType                 `json:"priceLinkType,omitempty"`
+	Price                    Money                    `json:"price,omitempty"`
+	TaxLotMethod             TaxLotMethod             `json:"taxLotMethod,omitempty"`
+	OrderLegCollection       []OrderLegCollection     `json:"orderLegCollection,omitempty"`
+	ActivationPrice          Money                    `json:"activationPrice,omitempty"`
+	SpecialInstruction       SpecialInstruction       `json:"specialInstruction,omitempty"`
+	OrderStrategyType        OrderStrategyType        `json:"orderStrategyType,omitempty"`
+	OrderId                  int                      `json:"orderId,omitempty"`
+	Cancelable               bool                     `json:"cancelable,omitempty"`
+	Editable                 bool                     `json:"editable,omitempty"`
+	Status                   OrderStatus              `json:"status,omitempty"`
+	EnteredTime              *TDTime                  `json:"enteredTime,omitempty"`
+	CloseTime                *TDTime                  `json:"closeTime,omitempty"`
+	Tag                      string                   `json:"tag,omitempty"`
+	AccountId                int                      `json:"accountId,omitempty"`
+	OrderActivityCollection  []Execution              `json:"orderActivityCollection,omitempty"`
+	ReplacingOrderCollection []Order                  `json:"replacingOrderCollection,omitempty"`
+	ChildOrderStrategies     []Order                  `json:"childOrderStrategies,omitempty"`
+	StatusDescription        string                   `json:"statusDescription,omitempty"`
+
+	// only set on saved orders
+	SavedOrderId int     `json:"savedOrderId,omitempty"`
+	SavedTime    *TDTime `json:"savedTime,omitempty"`
+}
+
+// CancelTime is when a good till cancel order expires.  TD documents it as
+// an object, {"date": "2021-05-14", "shortFormat": false}, but has been seen
+// to send the bare date string too; both are decoded.
+type CancelTime struct {
+	Date        TDDate `json:"date"`
+	ShortFormat bool   `json:"shortFormat"`
+}
+
+func (c *CancelTime) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		return c.Date.UnmarshalJSON(b)
+	}
+	type cancelTime CancelTime
+	return json.Unmarshal(b, (*cancelTime)(c))
+}
+
+// Time is the cancel date
+func (c CancelTime) Time() time.Time {
+	return time.Time(c.Date)
 }
 
 type OrderLegCollection struct {
-	OrderLegType   string `json:"orderLegType"` // 'EQUITY' or 'OPTION' or 'INDEX' or 'MUTUAL_FUND' or 'CASH_EQUIVALENT' or 'FIXED_INCOME' or 'CURRENCY'
-	LegId          int    `json:"legId"`
-	Instrument     string `json:"instrument"`     // The type <Instrument> has the following subclasses [Option, MutualFund, CashEquivalent, Equity, FixedIncome] descriptions are listed below\"
-	Instruction    string `json:"instruction"`    // 'BUY' or 'SELL' or 'BUY_TO_COVER' or 'SELL_SHORT' or 'BUY_TO_OPEN' or 'BUY_TO_CLOSE' or 'SELL_TO_OPEN' or 'SELL_TO_CLOSE' or 'EXCHANGE'
-	PositionEffect string `json:"positionEffect"` // 'OPENING' or 'CLOSING' or 'AUTOMATIC'
-	Quantity       int    `json:"quantity"`
-	QuantityType   string `json:"quantityType"` // 'ALL_SHARES' or 'DOLLARS' or 'SHARES'
-}
-
-/*
-//The class <Instrument> has the
-//following subclasses:
-//-Option
-//-MutualFund
-//-CashEquivalent
-//-Equity
-//-FixedIncome
-//JSON for each are listed below:
-
-//Option:
-{
-  "assetType": "'EQUITY' or 'OPTION' or 'INDEX' or 'MUTUAL_FUND' or 'CASH_EQUIVALENT' or 'FIXED_INCOME' or 'CURRENCY'",
-  "cusip": "string",
-  "symbol": "string",
-  "description": "string",
-  "type": "'VANILLA' or 'BINARY' or 'BARRIER'",
-  "putCall": "'PUT' or 'CALL'",
-  "underlyingSymbol": "string",
-  "optionMultiplier": 0,
-  "optionDeliverables": [
-    {
-      "symbol": "string",
-      "deliverableUnits": 0,
-      "currencyType": "'USD' or 'CAD' or 'EUR' or 'JPY'",
-      "assetType": "'EQUITY' or 'OPTION' or 'INDEX' or 'MUTUAL_FUND' or 'CASH_EQUIVALENT' or 'FIXED_INCOME' or 'CURRENCY'"
-    }
-  ]
-}
-
-//OR
-
-//MutualFund:
-{
-  "assetType": "'EQUITY' or 'OPTION' or 'INDEX' or 'MUTUAL_FUND' or 'CASH_EQUIVALENT' or 'FIXED_INCOME' or 'CURRENCY'",
-  "cusip": "string",
-  "symbol": "string",
-  "description": "string",
-  "type": "'NOT_APPLICABLE' or 'OPEN_END_NON_TAXABLE' or 'OPEN_END_TAXABLE' or 'NO_LOAD_NON_TAXABLE' or 'NO_LOAD_TAXABLE'"
-}
-
-//OR
-
-//CashEquivalent:
-{
-  "assetType": "'EQUITY' or 'OPTION' or 'INDEX' or 'MUTUAL_FUND' or 'CASH_EQUIVALENT' or 'FIXED_INCOME' or 'CURRENCY'",
-  "cusip": "string",
-  "symbol": "string",
-  "description": "string",
-  "type": "'SAVINGS' or 'MONEY_MARKET_FUND'"
-}
-
-//OR
-
-//Equity:
-{
-  "assetType": "'EQUITY' or 'OPTION' or 'INDEX' or 'MUTUAL_FUND' or 'CASH_EQUIVALENT' or 'FIXED_INCOME' or 'CURRENCY'",
-  "cusip": "string",
-  "symbol": "string",
-  "description": "string"
-}
-
-//OR
-
-//FixedIncome:
-{
-  "assetType": "'EQUITY' or 'OPTION' or 'INDEX' or 'MUTUAL_FUND' or 'CASH_EQUIVALENT' or 'FIXED_INCOME' or 'CURRENCY'",
-  "cusip": "string",
-  "symbol": "string",
-  "description": "string",
-  "maturityDate": "string",
-  "variableRate": 0,
-  "factor": 0
-}
-
-//The class <OrderActivity> has the
-//following subclasses:
-//-Execution
-//JSON for each are listed below:
-
-//Execution:
-{
-  "activityType": "'EXECUTION' or 'ORDER_ACTION'",
-  "executionType": "'FILL'",
-  "quantity": 0,
-  "orderRemainingQuantity": 0,
-  "executionLegs": [
-    {
-      "legId": 0,
-      "quantity": 0,
-      "mismarkedQuantity": 0,
-      "price": 0,
-      "time": "string"
-    }
-  ]
-}
-*/
+	OrderLegType   InstrumentType  `json:"orderLegType,omitempty"`
+	LegId          int             `json:"legId,omitempty"`
+	Instrument     OrderInstrument `json:"instrument,omitempty"`
+	Instruction    Instruction     `json:"instruction,omitempty"`
+	PositionEffect PositionEffect  `json:"positionEffect,omitempty"`
+	Quantity       float64         `json:"quantity,omitempty"`
+	QuantityType   QuantityType    `json:"quantityType,omitempty"`
+}
+
+func (l *OrderLegCollection) UnmarshalJSON(b []byte) error {
+	type leg OrderLegCollection
+	var raw struct {
+		leg
+		Instrument json.RawMessage `json:"instrument"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*l = OrderLegCollection(raw.leg)
+	if len(raw.Instrument) == 0 || string(raw.Instrument) == "null" {
+		return nil
+	}
+	inst, err := UnmarshalOrderInstrument(raw.Instrument)
+	if err != nil {
+		return err
+	}
+	l.Instrument = inst
+	return nil
+}
+
+// OrderInstrument is the instrument of an order leg, one of
+// *EquityInstrument, *OptionInstrument, *MutualFundInstrument,
+// *CashEquivalentInstrument or *FixedIncomeInstrument depending on its
+// assetType.  Asset types without their own fields decode as *InstrumentInfo.
+type OrderInstrument interface {
+	Info() *InstrumentInfo
+}
+
+// InstrumentInfo is common to every kind of instrument
+type InstrumentInfo struct {
+	AssetType   InstrumentType `json:"assetType"`
+	Cusip       string         `json:"cusip,omitempty"`
+	Symbol      string         `json:"symbol"`
+	Description string         `json:"description,omitempty"`
+}
+
+func (i *InstrumentInfo) Info() *InstrumentInfo { return i }
+
+type EquityInstrument struct {
+	InstrumentInfo
+}
+
+type OptionInstrument struct {
+	InstrumentInfo
+	Type               string              `json:"type,omitempty"` // 'VANILLA' or 'BINARY' or 'BARRIER'
+	PutCall            ContractType        `json:"putCall,omitempty"`
+	UnderlyingSymbol   string              `json:"underlyingSymbol,omitempty"`
+	OptionMultiplier   float64             `json:"optionMultiplier,omitempty"`
+	OptionDeliverables []OptionDeliverable `json:"optionDeliverables,omitempty"`
+}
+
+type OptionDeliverable struct {
+	Symbol           string         `json:"symbol"`
+	DeliverableUnits float64        `json:"deliverableUnits"`
+	CurrencyType     string         `json:"currencyType,omitempty"` // 'USD' or 'CAD' or 'EUR' or 'JPY'
+	AssetType        InstrumentType `json:"assetType"`
+}
+
+type MutualFundInstrument struct {
+	InstrumentInfo
+	Type string `json:"type,omitempty"` // 'NOT_APPLICABLE' or 'OPEN_END_NON_TAXABLE' or 'OPEN_END_TAXABLE' or 'NO_LOAD_NON_TAXABLE' or 'NO_LOAD_TAXABLE'
+}
+
+type CashEquivalentInstrument struct {
+	InstrumentInfo
+	Type string `json:"type,omitempty"` // 'SAVINGS' or 'MONEY_MARKET_FUND'
+}
+
+type FixedIncomeInstrument struct {
+	InstrumentInfo
+	MaturityDate *TDTime `json:"maturityDate,omitempty"`
+	VariableRate float64 `json:"variableRate,omitempty"`
+	Factor       float64 `json:"factor,omitempty"`
+}
+
+// UnmarshalOrderInstrument decodes an instrument into the type for its assetType
+func UnmarshalOrderInstrument(b []byte) (OrderInstrument, error) {
+	var info InstrumentInfo
+	if err := json.Unmarshal(b, &info); err != nil {
+		return nil, err
+	}
+
+	var inst OrderInstrument
+	switch info.AssetType {
+	case EQUITY:
+		inst = &EquityInstrument{}
+	case OPTION:
+		inst = &OptionInstrument{}
+	case MUTUAL_FUND:
+		inst = &MutualFundInstrument{}
+	case CASH_EQUIVALENT:
+		inst = &CashEquivalentInstrument{}
+	case FIXED_INCOME:
+		inst = &FixedIncomeInstrument{}
+	default:
+		return &info, nil
+	}
+	if err := json.Unmarshal(b, inst); err != nil {
+		return nil, fmt.Errorf("decoding %s instrument: %v", info.AssetType, err)
+	}
+	return inst, nil
+}
+
+// Execution is an entry in an order's orderActivityCollection, a fill
+// broken down by leg
+type Execution struct {
+	ActivityType           ActivityType   `json:"activityType"`
+	ActivityId             int64          `json:"activityId,omitempty"`
+	ExecutionType          ExecutionType  `json:"executionType,omitempty"`
+	Quantity               float64        `json:"quantity"`
+	OrderRemainingQuantity float64        `json:"orderRemainingQuantity"`
+	ExecutionLegs          []ExecutionLeg `json:"executionLegs,omitempty"`
+}
+
+type ExecutionLeg struct {
+	LegId             int     `json:"legId"`
+	Quantity          float64 `json:"quantity"`
+	MismarkedQuantity float64 `json:"mismarkedQuantity"`
+	Price             Money   `json:"price"`
+	Time              *TDTime `json:"time,omitempty"`
+}
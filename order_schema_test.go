@@ -0,0 +1,164 @@
+package tdam
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func loadOrders(t *testing.T) ([]byte, []Order) {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "orders_response.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var orders []Order
+	if err := dec.Decode(&orders); err != nil {
+		t.Fatal(err)
+	}
+	return b, orders
+}
+
+func TestOrderSchema(t *testing.T) {
+	_, orders := loadOrders(t)
+	if len(orders) != 3 {
+		t.Fatalf("got %d orders", len(orders))
+	}
+
+	spread := orders[0]
+	if spread.Price != Dollars(1.05) || spread.OrderId != 3811234567 || spread.Status != StatusFilled {
+		t.Errorf("unexpected order %+v", spread)
+	}
+	if want := time.Date(2020, 11, 16, 15, 31, 22, 0, time.UTC); !time.Time(*spread.EnteredTime).Equal(want) {
+		t.Errorf("entered %v, want %v", time.Time(*spread.EnteredTime), want)
+	}
+	put, ok := spread.OrderLegCollection[0].Instrument.(*OptionInstrument)
+	if !ok {
+		t.Fatalf("expected option instrument, got %T", spread.OrderLegCollection[0].Instrument)
+	}
+	if put.PutCall != PUT || put.OptionMultiplier != 100 || put.OptionDeliverables[0].Symbol != "SPY" || put.Symbol != "SPY_121820P290" {
+		t.Errorf("unexpected option %+v", put)
+	}
+	exec := spread.OrderActivityCollection[0]
+	if exec.ActivityType != ActivityExecution || len(exec.ExecutionLegs) != 2 || exec.ExecutionLegs[1].Price != Dollars(2.39) {
+		t.Errorf("unexpected execution %+v", exec)
+	}
+
+	trigger := orders[1]
+	if trigger.CancelTime.Time().Format("2006-01-02") != "2021-05-14" {
+		t.Errorf("cancel time %v", trigger.CancelTime.Time())
+	}
+	if trigger.ReplacingOrderCollection[0].Status != StatusReplaced {
+		t.Errorf("unexpected replaced order %+v", trigger.ReplacingOrderCollection[0])
+	}
+	stop := trigger.ChildOrderStrategies[0].ChildOrderStrategies[1]
+	if stop.StopPrice != Dollars(110.25) || stop.OrderLegCollection[0].Instrument.Info().Cusip != "037833100" {
+		t.Errorf("unexpected stop %+v", stop)
+	}
+
+	legs := orders[2].OrderLegCollection
+	for i, want := range []OrderInstrument{&MutualFundInstrument{}, &CashEquivalentInstrument{}, &FixedIncomeInstrument{}, &InstrumentInfo{}} {
+		if reflect.TypeOf(legs[i].Instrument) != reflect.TypeOf(want) {
+			t.Errorf("leg %d: got %T, want %T", i+1, legs[i].Instrument, want)
+		}
+	}
+	if mf := legs[0].Instrument.(*MutualFundInstrument); mf.Type != "NO_LOAD_TAXABLE" || legs[0].QuantityType != QuantityDollars {
+		t.Errorf("unexpected mutual fund leg %+v", legs[0])
+	}
+	if fi := legs[2].Instrument.(*FixedIncomeInstrument); fi.VariableRate != 1.75 || time.Time(*fi.MaturityDate).Year() != 2024 {
+		t.Errorf("unexpected fixed income %+v", fi)
+	}
+}
+
+// drops the zero values that omitempty won't write back out
+func dropZero(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := map[string]interface{}{}
+		for k, e := range v {
+			if e == false || e == 0.0 || e == "" {
+				continue
+			}
+			out[k] = dropZero(e)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = dropZero(e)
+		}
+		return out
+	}
+	return v
+}
+
+func TestOrderSchemaRoundTrip(t *testing.T) {
+	captured, orders := loadOrders(t)
+	encoded, err := json.Marshal(orders)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want, got interface{}
+	if err := json.Unmarshal(captured, &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(encoded, &got); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := dropZero(want), dropZero(got); !reflect.DeepEqual(want, got) {
+		w, _ := json.MarshalIndent(want, "", "  ")
+		g, _ := json.MarshalIndent(got, "", "  ")
+		t.Errorf("round trip mismatch\nwant %s\ngot  %s", w, g)
+	}
+}
+
+func TestTDDateUnmarshal(t *testing.T) {
+	var d TDDate
+	if err := json.Unmarshal([]byte(`"2021-03-19"`), &d); err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2021, 3, 19, 0, 0, 0, 0, time.UTC); !time.Time(d).Equal(want) {
+		t.Errorf("date %v, want %v", time.Time(d), want)
+	}
+
+	for _, bad := range []string{`5`, `12345`, `""`, `"5"`, `true`} {
+		var d TDDate
+		if err := json.Unmarshal([]byte(bad), &d); err == nil {
+			t.Errorf("expected %s to fail as a date", bad)
+		}
+	}
+}
+
+func TestCancelTimeUnmarshal(t *testing.T) {
+	want := time.Date(2021, 5, 14, 0, 0, 0, 0, time.UTC)
+	for _, payload := range []string{
+		`{"date": "2021-05-14", "shortFormat": true}`,
+		`"2021-05-14"`,
+	} {
+		var c CancelTime
+		if err := json.Unmarshal([]byte(payload), &c); err != nil {
+			t.Fatalf("%s: %v", payload, err)
+		}
+		if !c.Time().Equal(want) {
+			t.Errorf("%s: cancel time %v, want %v", payload, c.Time(), want)
+		}
+	}
+
+	b, err := json.Marshal(CancelTime{Date: TDDate(want)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"date":"2021-05-14","shortFormat":false}` {
+		t.Errorf("cancel time encoded as %s", b)
+	}
+
+	if err := json.Unmarshal([]byte(`5`), new(CancelTime)); err == nil {
+		t.Errorf("expected a number to fail as a cancel time")
+	}
+}
@@ -0,0 +1,177 @@
+package tdam_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ianmcmahon/tdam"
+	"github.com/ianmcmahon/tdam/tdamtest"
+)
+
+func TestOrders(t *testing.T) {
+	srv := tdamtest.NewServer()
+	defer srv.Close()
+
+	accounts, err := srv.Client().GetAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := accounts[0]
+
+	order, err := tdam.Equity("SPY").Buy(1).Limit(400).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := a.PlaceOrder(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	placed, err := a.GetOrder(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if placed.OrderId != id || placed.Status != tdam.StatusQueued || placed.Price != tdam.Dollars(400) {
+		t.Errorf("unexpected order: %#v", placed)
+	}
+
+	newID, err := a.ReplaceOrder(id, order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newID == id {
+		t.Errorf("replacement should have a new id")
+	}
+	if err := a.CancelOrder(newID); err != nil {
+		t.Fatal(err)
+	}
+	var apiErr *tdam.APIError
+	if err := a.CancelOrder(id); !errors.As(err, &apiErr) || apiErr.StatusCode != 400 {
+		t.Errorf("replaced order shouldn't be cancelable, got %v", err)
+	}
+
+	canceled, err := a.GetOrders(tdam.OrderFilter{Status: "CANCELED"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(canceled) != 1 || canceled[0].OrderId != newID {
+		t.Errorf("unexpected canceled orders: %#v", canceled)
+	}
+
+	if _, err := a.GetOrder(99); !tdam.IsNotFound(err) {
+		t.Errorf("expected not found, got %v", err)
+	}
+
+	endpoint := "/v1/accounts/" + tdamtest.AccountID + "/orders"
+	want := []string{
+		"GET /v1/accounts",
+		"POST " + endpoint,
+		fmt.Sprintf("GET %s/%d", endpoint, id),
+		fmt.Sprintf("PUT %s/%d", endpoint, id),
+		fmt.Sprintf("DELETE %s/%d", endpoint, newID),
+		fmt.Sprintf("DELETE %s/%d", endpoint, id),
+	}
+	got := srv.Requests()
+	if len(got) < len(want) {
+		t.Fatalf("unexpected requests %v", got)
+	}
+	for i, w := range want {
+		if got[i] != w {
+			t.Errorf("request %d = %q, want %q", i, got[i], w)
+		}
+	}
+}
+
+func TestBracketOrder(t *testing.T) {
+	srv := tdamtest.NewServer()
+	defer srv.Close()
+
+	accounts, err := srv.Client().GetAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := accounts[0]
+
+	order, err := tdam.Equity("SPY").Buy(1).Limit(400).Bracket(420, 390)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := a.PlaceOrder(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	placed, err := a.GetOrder(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if placed.Status != tdam.StatusQueued || len(placed.ChildOrderStrategies) != 1 {
+		t.Fatalf("unexpected entry: %#v", placed)
+	}
+	exits := placed.ChildOrderStrategies[0].ChildOrderStrategies
+	if len(exits) != 2 {
+		t.Fatalf("unexpected exits: %#v", exits)
+	}
+	for _, exit := range exits {
+		if exit.Status != tdam.StatusAwaitingParentOrder || exit.OrderId == 0 {
+			t.Errorf("exits should wait on the entry, got %#v", exit)
+		}
+	}
+	if exits[0].Price != tdam.Dollars(420) || exits[1].StopPrice != tdam.Dollars(390) {
+		t.Errorf("unexpected exit prices %v and %v", exits[0].Price, exits[1].StopPrice)
+	}
+
+	var apiErr *tdam.APIError
+	if _, err := a.PlaceOrder(tdam.OCO(order)); !errors.As(err, &apiErr) || apiErr.StatusCode != 400 {
+		t.Errorf("expected invalid OCO to be rejected, got %v", err)
+	}
+}
+
+func TestOrderIDFromLocation(t *testing.T) {
+	for _, tc := range []struct {
+		location string
+		id       int
+		err      string
+	}{
+		{location: "https://api.tdameritrade.com/v1/accounts/123/orders/456", id: 456},
+		{location: "/v1/accounts/123/orders/789?foo=bar", id: 789},
+		{location: "", err: "no Location header"},
+		{location: "https://api.tdameritrade.com/v1/accounts/123/orders/abc", err: "can't parse order id"},
+	} {
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if tc.location != "" {
+				w.Header().Set("Location", tc.location)
+			}
+			w.WriteHeader(http.StatusCreated)
+		}))
+
+		c := tdam.NewClient("KEY")
+		c.APIBaseURL = api.URL
+		c.RateLimiter = nil
+		c.TokenStore = tdam.NewMemoryTokenStore()
+		c.TokenStore.SaveToken(&tdam.TokenResponse{
+			AccessToken:   "access",
+			AccessExpiry:  time.Now().Add(time.Hour),
+			RefreshToken:  "refresh",
+			RefreshExpiry: time.Now().Add(time.Hour),
+		})
+		a := &tdam.Account{Client: c}
+		a.AccountId = "123"
+
+		id, err := a.PlaceOrder(tdam.Order{})
+		api.Close()
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%q: expected %q error, got %d, %v", tc.location, tc.err, id, err)
+			}
+			continue
+		}
+		if err != nil || id != tc.id {
+			t.Errorf("%q: got %d, %v, want %d", tc.location, id, err, tc.id)
+		}
+	}
+}
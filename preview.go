@@ -0,0 +1,485 @@
+package tdam
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+type OptionTradingLevel string
+
+const (
+	OptionLevelNone    OptionTradingLevel = "NONE"
+	OptionLevelLong    OptionTradingLevel = "LONG"
+	OptionLevelCovered OptionTradingLevel = "COVERED"
+	OptionLevelSpread  OptionTradingLevel = "SPREAD"
+	OptionLevelFull    OptionTradingLevel = "FULL"
+)
+
+var optionLevelRank = map[OptionTradingLevel]int{
+	OptionLevelNone:    0,
+	OptionLevelLong:    1,
+	OptionLevelCovered: 2,
+	OptionLevelSpread:  3,
+	OptionLevelFull:    4,
+}
+
+// Allows reports whether an account at level l can place trades needing level need
+func (l OptionTradingLevel) Allows(need OptionTradingLevel) bool {
+	return optionLevelRank[l] >= optionLevelRank[need]
+}
+
+type ViolationCode string
+
+const (
+	ViolationInvalidOrder      ViolationCode = "INVALID_ORDER"
+	ViolationInsufficientFunds ViolationCode = "INSUFFICIENT_FUNDS"
+	ViolationMarginRequired    ViolationCode = "MARGIN_REQUIRED"
+	ViolationClosingOnly       ViolationCode = "CLOSING_ONLY"
+	ViolationOptionLevel       ViolationCode = "OPTION_LEVEL"
+	ViolationPatternDayTrader  ViolationCode = "PATTERN_DAY_TRADER"
+	ViolationNoPosition        ViolationCode = "NO_POSITION"
+)
+
+// Violation is a reason TD would reject an order.  Leg is the leg id it
+// applies to, or 0 for the order as a whole.
+type Violation struct {
+	Code    ViolationCode
+	Leg     int
+	Message string
+}
+
+func (v Violation) String() string {
+	if v.Leg > 0 {
+		return fmt.Sprintf("%s (leg %d): %s", v.Code, v.Leg, v.Message)
+	}
+	return fmt.Sprintf("%s: %s", v.Code, v.Message)
+}
+
+// OrderPreview is the result of checking an order against the account
+type OrderPreview struct {
+	Violations []Violation
+	Warnings   []string
+
+	// BuyingPowerEffect is how much the order would change buying power,
+	// negative when it uses it.  It's only an estimate when Estimated is
+	// set; market orders without a position to price them can't be.
+	BuyingPowerEffect Money
+	Estimated         bool
+
+	// the option approval level the order needs, empty for equity orders
+	RequiredOptionLevel OptionTradingLevel
+}
+
+func (p *OrderPreview) OK() bool {
+	return len(p.Violations) == 0
+}
+
+// Err returns a *PreviewError if there are violations
+func (p *OrderPreview) Err() error {
+	if p.OK() {
+		return nil
+	}
+	return &PreviewError{Violations: p.Violations}
+}
+
+type PreviewError struct {
+	Violations []Violation
+}
+
+func (e *PreviewError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+	return "order would be rejected: " + strings.Join(msgs, "; ")
+}
+
+// the TD minimum for pattern day traders
+const patternDayTraderMinimum = 25000 * Dollar
+
+// PreviewOrder checks order against the account's balances, positions and
+// restrictions without sending it.  It's only as current as the account,
+// so fetch a fresh one with GetAccounts before previewing.
+//
+// For TRIGGER orders only the first order is previewed, since its children
+// close the position it opens.  OCO orders are previewed as whichever child
+// uses the most buying power.
+func (a *Account) PreviewOrder(order Order) *OrderPreview {
+	p := &OrderPreview{}
+	if err := order.Validate(); err != nil {
+		for _, e := range err.(*ValidationError).Errors {
+			p.Violations = append(p.Violations, Violation{Code: ViolationInvalidOrder, Message: e})
+		}
+		return p
+	}
+
+	if order.OrderStrategyType == StrategyOCO {
+		p.Estimated = true
+		for i, child := range order.ChildOrderStrategies {
+			cp := a.PreviewOrder(child)
+			for _, v := range cp.Violations {
+				v.Message = fmt.Sprintf("child order %d: %s", i+1, v.Message)
+				p.Violations = append(p.Violations, v)
+			}
+			p.Warnings = append(p.Warnings, cp.Warnings...)
+			if i == 0 || cp.BuyingPowerEffect < p.BuyingPowerEffect {
+				p.BuyingPowerEffect = cp.BuyingPowerEffect
+			}
+			p.Estimated = p.Estimated && cp.Estimated
+			if !p.RequiredOptionLevel.Allows(cp.RequiredOptionLevel) {
+				p.RequiredOptionLevel = cp.RequiredOptionLevel
+			}
+		}
+		return p
+	}
+
+	pv := &previewer{a: a, order: order, p: p}
+	pv.run()
+	return p
+}
+
+type previewer struct {
+	a     *Account
+	order Order
+	p     *OrderPreview
+
+	opening []OrderLegCollection
+	closing []OrderLegCollection
+	options bool
+}
+
+func (pv *previewer) violate(code ViolationCode, leg int, format string, args ...interface{}) {
+	pv.p.Violations = append(pv.p.Violations, Violation{Code: code, Leg: leg, Message: fmt.Sprintf(format, args...)})
+}
+
+func (pv *previewer) warn(format string, args ...interface{}) {
+	pv.p.Warnings = append(pv.p.Warnings, fmt.Sprintf(format, args...))
+}
+
+func isOpening(i Instruction) bool {
+	switch i {
+	case InstructionBuy, InstructionSellShort, InstructionBuyToOpen, InstructionSellToOpen:
+		return true
+	}
+	return false
+}
+
+func isBuy(i Instruction) bool {
+	switch i {
+	case InstructionBuy, InstructionBuyToCover, InstructionBuyToOpen, InstructionBuyToClose:
+		return true
+	}
+	return false
+}
+
+func (pv *previewer) run() {
+	for _, leg := range pv.order.OrderLegCollection {
+		if isOpening(leg.Instruction) {
+			pv.opening = append(pv.opening, leg)
+		} else {
+			pv.closing = append(pv.closing, leg)
+		}
+		if leg.Instrument.Info().AssetType == OPTION {
+			pv.options = true
+		}
+	}
+
+	pv.checkRestrictions()
+	pv.checkPositions()
+	if pv.options {
+		pv.previewOptions()
+	} else {
+		pv.previewEquities()
+	}
+	pv.checkFunds()
+}
+
+func (pv *previewer) checkRestrictions() {
+	a := pv.a
+	if a.IsClosingOnlyRestricted {
+		for _, leg := range pv.opening {
+			pv.violate(ViolationClosingOnly, leg.LegId, "account is restricted to closing transactions, can't %s", leg.Instruction)
+		}
+	}
+
+	if a.Type == CASH {
+		for _, leg := range pv.opening {
+			if leg.Instruction == InstructionSellShort {
+				pv.violate(ViolationMarginRequired, leg.LegId, "short selling needs a margin account")
+			}
+		}
+	}
+
+	// TD stops pattern day traders under the minimum from opening anything,
+	// and flags anyone making a fourth round trip in five days
+	underMinimum := a.CurrentBalances.LiquidationValue < patternDayTraderMinimum
+	if a.Type == MARGIN && underMinimum {
+		if a.IsDayTrader && len(pv.opening) > 0 {
+			pv.violate(ViolationPatternDayTrader, 0, "account is flagged as a pattern day trader with less than %s equity", patternDayTraderMinimum.Dollars())
+		} else if a.RoundTrips >= 3 && len(pv.closing) > 0 {
+			pv.warn("%d day trades in the last 5 days, closing a position opened today will flag the account as a pattern day trader", a.RoundTrips)
+		}
+	}
+}
+
+// net long (positive) or short quantity held of symbol
+func (a *Account) positionQuantity(symbol string) float64 {
+	var q float64
+	for _, p := range a.RawPositions {
+		if p.Instrument != nil && string(p.Instrument.Symbol) == symbol {
+			q += p.LongQuantity - p.ShortQuantity
+		}
+	}
+	return q
+}
+
+// price per share or contract from the position's market value
+func (a *Account) positionPrice(symbol string) (Money, bool) {
+	for _, p := range a.RawPositions {
+		if p.Instrument == nil || string(p.Instrument.Symbol) != symbol {
+			continue
+		}
+		q := p.LongQuantity - p.ShortQuantity
+		if q == 0 {
+			continue
+		}
+		if p.Instrument.AssetType == OPTION {
+			q *= 100
+		}
+		return p.MarketValue.Mul(1 / q).Abs(), true
+	}
+	return 0, false
+}
+
+func (pv *previewer) checkPositions() {
+	for _, leg := range pv.closing {
+		sym := leg.Instrument.Info().Symbol
+		held := pv.a.positionQuantity(sym)
+		switch leg.Instruction {
+		case InstructionSell, InstructionSellToClose:
+			if held < leg.Quantity {
+				pv.violate(ViolationNoPosition, leg.LegId, "%s %g %s but only %g held long", leg.Instruction, leg.Quantity, sym, math.Max(held, 0))
+			}
+		case InstructionBuyToCover, InstructionBuyToClose:
+			if -held < leg.Quantity {
+				pv.violate(ViolationNoPosition, leg.LegId, "%s %g %s but only %g held short", leg.Instruction, leg.Quantity, sym, math.Max(-held, 0))
+			}
+		}
+	}
+}
+
+// the price the order will trade at per unit, if we can tell
+func (pv *previewer) price() (Money, bool) {
+	o := pv.order
+	switch o.OrderType {
+	case OrderTypeLimit, OrderTypeStopLimit, OrderTypeNetDebit, OrderTypeNetCredit:
+		return o.Price, true
+	case OrderTypeStop:
+		return o.StopPrice, true
+	case OrderTypeNetZero:
+		return 0, true
+	}
+	if len(o.OrderLegCollection) == 1 {
+		return pv.a.positionPrice(o.OrderLegCollection[0].Instrument.Info().Symbol)
+	}
+	return 0, false
+}
+
+func (pv *previewer) previewEquities() {
+	price, ok := pv.price()
+	if !ok {
+		pv.warn("no price for a %s order, buying power effect not estimated", pv.order.OrderType)
+		return
+	}
+	pv.p.Estimated = true
+	for _, leg := range pv.order.OrderLegCollection {
+		// closing doesn't use buying power, and the proceeds may not be settled
+		if isOpening(leg.Instruction) {
+			pv.p.BuyingPowerEffect -= price.Mul(leg.Quantity)
+		}
+	}
+}
+
+// payoffLeg is an opening leg's value at expiration as a function of the
+// underlying price
+type payoffLeg struct {
+	leg    OrderLegCollection
+	option OptionSymbol
+	equity bool
+	units  float64 // shares, contracts times 100, negative when short
+}
+
+func (l payoffLeg) value(s float64) float64 {
+	switch {
+	case l.equity:
+		return l.units * s
+	case l.option.PutCall == CALL:
+		return l.units * math.Max(0, s-l.option.Strike)
+	default:
+		return l.units * math.Max(0, l.option.Strike-s)
+	}
+}
+
+func (pv *previewer) previewOptions() {
+	o := pv.order
+	var legs []payoffLeg
+	var shortCalls, shortPuts, longOptions, shares float64
+	for _, leg := range pv.opening {
+		units := leg.Quantity
+		if !isBuy(leg.Instruction) {
+			units = -units
+		}
+		inst := leg.Instrument.Info()
+		if inst.AssetType == EQUITY {
+			legs = append(legs, payoffLeg{leg: leg, equity: true, units: units})
+			shares += units
+			continue
+		}
+		sym, err := ParseOptionSymbol(inst.Symbol)
+		if err != nil {
+			pv.violate(ViolationInvalidOrder, leg.LegId, "%v", err)
+			return
+		}
+		legs = append(legs, payoffLeg{leg: leg, option: sym, units: units * 100})
+		switch {
+		case units > 0:
+			longOptions += units
+		case sym.PutCall == CALL:
+			shortCalls -= units
+		default:
+			shortPuts -= units
+		}
+	}
+	// existing stock covers short calls too
+	for _, leg := range legs {
+		if !leg.equity && leg.option.PutCall == CALL && leg.units < 0 {
+			shares += math.Max(0, pv.a.positionQuantity(leg.option.Underlying))
+			break
+		}
+	}
+
+	// net premium, positive for a credit
+	price, havePrice := pv.price()
+	var premium Money
+	switch o.OrderType {
+	case OrderTypeNetCredit:
+		premium = price.Mul(o.Quantity * 100)
+	case OrderTypeNetDebit:
+		premium = -price.Mul(o.Quantity * 100)
+	case OrderTypeNetZero:
+	default:
+		if len(o.OrderLegCollection) == 1 {
+			leg := o.OrderLegCollection[0]
+			premium = price.Mul(leg.Quantity * 100)
+			if isBuy(leg.Instruction) {
+				premium = -premium
+			}
+		}
+	}
+
+	if len(legs) == 0 {
+		// closing only, paying to buy back a short uses cash
+		if havePrice {
+			pv.p.Estimated = true
+			if premium < 0 {
+				pv.p.BuyingPowerEffect = premium
+			}
+		} else {
+			pv.warn("no price for a %s order, buying power effect not estimated", o.OrderType)
+		}
+		return
+	}
+
+	maxLoss, bounded := maxLoss(legs, premium.Float64())
+	level := OptionLevelLong
+	effect := premium
+	switch {
+	case shortCalls == 0 && shortPuts == 0:
+		// long options only, the debit is all there is
+	case shortPuts == 0 && longOptions == 0 && shortCalls*100 <= shares:
+		level = OptionLevelCovered
+	case shortCalls == 0 && longOptions == 0 && len(legs) == 1:
+		// cash secured put
+		level = OptionLevelCovered
+		effect = -Dollars(legs[0].option.Strike).Mul(-legs[0].units) + premium
+	case bounded:
+		level = OptionLevelSpread
+		effect = -Dollars(maxLoss)
+	default:
+		level = OptionLevelFull
+		if pv.a.Type == CASH {
+			pv.violate(ViolationMarginRequired, 0, "uncovered short options need a margin account")
+		}
+		pv.warn("margin requirement for uncovered options isn't estimated")
+		havePrice = false
+	}
+	pv.p.RequiredOptionLevel = level
+
+	if l := pv.a.OptionTradingLevel; l != "" && !l.Allows(level) {
+		pv.violate(ViolationOptionLevel, 0, "needs option trading level %s, account has %s", level, l)
+	}
+
+	if !havePrice {
+		if o.OrderType == OrderTypeMarket {
+			pv.warn("no price for a MARKET order, buying power effect not estimated")
+		}
+		return
+	}
+	pv.p.Estimated = true
+	pv.p.BuyingPowerEffect = effect
+}
+
+// maxLoss is the worst loss at expiration for the legs plus the premium
+// collected, or false if it's unbounded.  Payoffs are piecewise linear
+// between strikes, so checking zero, each strike and the slope above the
+// highest strike is enough.
+func maxLoss(legs []payoffLeg, premium float64) (float64, bool) {
+	slope := 0.0
+	points := []float64{0}
+	for _, l := range legs {
+		if l.equity || l.option.PutCall == CALL {
+			slope += l.units
+		}
+		if !l.equity {
+			points = append(points, l.option.Strike)
+		}
+	}
+	if slope < 0 {
+		return 0, false
+	}
+	worst := math.Inf(1)
+	for _, s := range points {
+		pnl := premium
+		for _, l := range legs {
+			pnl += l.value(s)
+		}
+		worst = math.Min(worst, pnl)
+	}
+	return math.Max(0, -worst), true
+}
+
+// buying power available for the order
+func (pv *previewer) available() Money {
+	b := pv.a.CurrentBalances
+	if pv.a.Type == MARGIN {
+		if pv.options && b.AvailableFunds != 0 {
+			// options can't be bought on margin
+			return b.AvailableFunds
+		}
+		if !pv.options && b.BuyingPower != 0 {
+			return b.BuyingPower
+		}
+	}
+	return b.CashAvailableForTrading
+}
+
+func (pv *previewer) checkFunds() {
+	effect := pv.p.BuyingPowerEffect
+	if !pv.p.Estimated || effect >= 0 {
+		return
+	}
+	if avail := pv.available(); -effect > avail {
+		pv.violate(ViolationInsufficientFunds, 0, "needs %s of buying power, %s available", (-effect).Dollars(), avail.Dollars())
+	}
+}
@@ -0,0 +1,163 @@
+package tdam
+
+import (
+	"testing"
+)
+
+func previewAccount(typ AccountType, cash Money, positions ...*Position) *Account {
+	return &Account{
+		SecuritiesAccount: SecuritiesAccount{
+			Type:         typ,
+			AccountId:    "123",
+			RawPositions: positions,
+			CurrentBalances: Balances{
+				CashAvailableForTrading: cash,
+				AvailableFunds:          cash,
+				BuyingPower:             2 * cash,
+				LiquidationValue:        cash,
+			},
+		},
+		OptionTradingLevel: OptionLevelSpread,
+	}
+}
+
+func position(assetType InstrumentType, symbol string, quantity float64, value Money) *Position {
+	p := &Position{
+		Instrument:  &Instrument{AssetType: assetType, Symbol: Symbol(symbol)},
+		MarketValue: value,
+	}
+	if quantity > 0 {
+		p.LongQuantity = quantity
+	} else {
+		p.ShortQuantity = -quantity
+	}
+	return p
+}
+
+func mustBuild(t *testing.T, b *OrderBuilder) Order {
+	t.Helper()
+	o, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func hasViolation(p *OrderPreview, code ViolationCode) bool {
+	for _, v := range p.Violations {
+		if v.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPreviewEquity(t *testing.T) {
+	a := previewAccount(MARGIN, 30000*Dollar, position(EQUITY, "AAPL", 100, 15000*Dollar))
+
+	p := a.PreviewOrder(mustBuild(t, Equity("MSFT").Buy(100).Limit(200)))
+	if !p.OK() || !p.Estimated || p.BuyingPowerEffect != -20000*Dollar {
+		t.Errorf("unexpected preview %+v", p)
+	}
+
+	p = a.PreviewOrder(mustBuild(t, Equity("MSFT").Buy(400).Limit(200)))
+	if !hasViolation(p, ViolationInsufficientFunds) {
+		t.Errorf("expected insufficient funds, got %+v", p)
+	}
+
+	p = a.PreviewOrder(mustBuild(t, Equity("AAPL").Sell(200).Market()))
+	if !hasViolation(p, ViolationNoPosition) {
+		t.Errorf("expected no position, got %+v", p)
+	}
+
+	p = a.PreviewOrder(mustBuild(t, Equity("AAPL").Sell(100).Market()))
+	if !p.OK() || p.BuyingPowerEffect != 0 {
+		t.Errorf("closing should be fine, got %+v", p)
+	}
+
+	p = a.PreviewOrder(mustBuild(t, Equity("MSFT").Buy(1).Market()))
+	if !p.OK() || p.Estimated || len(p.Warnings) == 0 {
+		t.Errorf("market order without a price shouldn't be estimated, got %+v", p)
+	}
+
+	cash := previewAccount(CASH, 30000*Dollar)
+	if p := cash.PreviewOrder(mustBuild(t, Equity("MSFT").SellShort(10).Limit(200))); !hasViolation(p, ViolationMarginRequired) {
+		t.Errorf("expected margin required, got %+v", p)
+	}
+
+	a.IsClosingOnlyRestricted = true
+	if p := a.PreviewOrder(mustBuild(t, Equity("MSFT").Buy(1).Limit(200))); !hasViolation(p, ViolationClosingOnly) {
+		t.Errorf("expected closing only, got %+v", p)
+	}
+}
+
+func TestPreviewPatternDayTrader(t *testing.T) {
+	a := previewAccount(MARGIN, 10000*Dollar, position(EQUITY, "AAPL", 100, 15000*Dollar))
+	a.RoundTrips = 3
+	if p := a.PreviewOrder(mustBuild(t, Equity("AAPL").Sell(100).Limit(150))); !p.OK() || len(p.Warnings) == 0 {
+		t.Errorf("expected a day trade warning, got %+v", p)
+	}
+	a.IsDayTrader = true
+	if p := a.PreviewOrder(mustBuild(t, Equity("MSFT").Buy(1).Limit(200))); !hasViolation(p, ViolationPatternDayTrader) {
+		t.Errorf("expected pattern day trader violation, got %+v", p)
+	}
+}
+
+func TestPreviewOptions(t *testing.T) {
+	a := previewAccount(MARGIN, 5000*Dollar, position(EQUITY, "SPY", 100, 30000*Dollar))
+
+	spread, _ := Vertical("SPY_121820P290", "SPY_121820P300")
+	p := a.PreviewOrder(mustBuild(t, spread.Open(2, 3)))
+	// 2 x $10 wide less the $3 credit
+	if !p.OK() || p.RequiredOptionLevel != OptionLevelSpread || p.BuyingPowerEffect != -1400*Dollar {
+		t.Errorf("unexpected credit spread preview %+v", p)
+	}
+
+	debit, _ := Vertical("SPY_121820C300", "SPY_121820C310")
+	if p := a.PreviewOrder(mustBuild(t, debit.Open(1, 4))); p.BuyingPowerEffect != -400*Dollar {
+		t.Errorf("unexpected debit spread preview %+v", p)
+	}
+
+	if p := a.PreviewOrder(mustBuild(t, OptionLeg("SPY_121820C310").SellToOpen(1).Limit(2))); !p.OK() ||
+		p.RequiredOptionLevel != OptionLevelCovered || p.BuyingPowerEffect != 200*Dollar {
+		t.Errorf("expected a covered call, got %+v", p)
+	}
+
+	if p := a.PreviewOrder(mustBuild(t, OptionLeg("SPY_121820P290").SellToOpen(1).Limit(2))); !hasViolation(p, ViolationInsufficientFunds) ||
+		p.RequiredOptionLevel != OptionLevelCovered || p.BuyingPowerEffect != -28800*Dollar {
+		t.Errorf("expected an unaffordable cash secured put, got %+v", p)
+	}
+
+	straddle, _ := Straddle("SPY_121820P300", "SPY_121820C300")
+	p = a.PreviewOrder(mustBuild(t, straddle.Reverse().Open(2, 12)))
+	if !hasViolation(p, ViolationOptionLevel) || p.RequiredOptionLevel != OptionLevelFull || p.Estimated {
+		t.Errorf("expected naked straddle to need FULL, got %+v", p)
+	}
+
+	if p := a.PreviewOrder(mustBuild(t, OptionLeg("SPY_121820P290").SellToClose(1).Limit(2))); !hasViolation(p, ViolationNoPosition) {
+		t.Errorf("expected no position, got %+v", p)
+	}
+
+	a.OptionTradingLevel = OptionLevelLong
+	if p := a.PreviewOrder(mustBuild(t, spread.Open(1, 3))); !hasViolation(p, ViolationOptionLevel) {
+		t.Errorf("expected option level violation, got %+v", p)
+	}
+	if err := a.PreviewOrder(mustBuild(t, spread.Open(1, 3))).Err(); err == nil {
+		t.Errorf("expected an error")
+	}
+}
+
+func TestPreviewBracket(t *testing.T) {
+	a := previewAccount(MARGIN, 5000*Dollar)
+	spread, _ := Vertical("SPY_121820P290", "SPY_121820P300")
+	order, err := spread.Bracket(1, 3, 1.5, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := a.PreviewOrder(order); !p.OK() || p.BuyingPowerEffect != -700*Dollar {
+		t.Errorf("unexpected bracket preview %+v", p)
+	}
+	if p := a.PreviewOrder(Order{}); !hasViolation(p, ViolationInvalidOrder) {
+		t.Errorf("expected invalid order, got %+v", p)
+	}
+}
@@ -0,0 +1,208 @@
+package tdam
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type PeriodType string
+
+const (
+	PeriodDay   PeriodType = "day"
+	PeriodMonth PeriodType = "month"
+	PeriodYear  PeriodType = "year"
+	PeriodYTD   PeriodType = "ytd"
+)
+
+type FrequencyType string
+
+const (
+	FrequencyMinute  FrequencyType = "minute"
+	FrequencyDaily   FrequencyType = "daily"
+	FrequencyWeekly  FrequencyType = "weekly"
+	FrequencyMonthly FrequencyType = "monthly"
+)
+
+// what TD accepts for each period type, the first frequency type is the default
+var priceHistoryRules = map[PeriodType]struct {
+	periods     []int
+	frequencies []FrequencyType
+}{
+	PeriodDay:   {[]int{1, 2, 3, 4, 5, 10}, []FrequencyType{FrequencyMinute}},
+	PeriodMonth: {[]int{1, 2, 3, 6}, []FrequencyType{FrequencyWeekly, FrequencyDaily}},
+	PeriodYear:  {[]int{1, 2, 3, 5, 10, 15, 20}, []FrequencyType{FrequencyMonthly, FrequencyDaily, FrequencyWeekly}},
+	PeriodYTD:   {[]int{1}, []FrequencyType{FrequencyWeekly, FrequencyDaily}},
+}
+
+var minuteFrequencies = []int{1, 5, 10, 15, 30}
+
+// TD cuts minute bars off somewhere past this much per request, so longer
+// minute ranges are fetched in pieces
+const minuteChunk = 10 * 24 * time.Hour
+
+// Candle is one OHLCV bar
+type Candle struct {
+	Open     Money `json:"open"`
+	High     Money `json:"high"`
+	Low      Money `json:"low"`
+	Close    Money `json:"close"`
+	Volume   int64 `json:"volume"`
+	Datetime int64 `json:"datetime"` // epoch millis of the bar's start
+}
+
+func (c Candle) Time() time.Time {
+	return millisToTime(c.Datetime)
+}
+
+func (c Candle) String() string {
+	return fmt.Sprintf("%s O %s H %s L %s C %s V %d", c.Time().Format("2006-01-02 15:04"),
+		c.Open, c.High, c.Low, c.Close, c.Volume)
+}
+
+type priceHistoryResponse struct {
+	Symbol  string   `json:"symbol"`
+	Empty   bool     `json:"empty"`
+	Candles []Candle `json:"candles"`
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// validates the combination and fills in defaults for zero values
+func priceHistoryParams(periodType PeriodType, period int, frequencyType FrequencyType, frequency int, start, end time.Time) (PeriodType, FrequencyType, int, error) {
+	if periodType == "" {
+		periodType = PeriodDay
+	}
+	rules, ok := priceHistoryRules[periodType]
+	if !ok {
+		return periodType, frequencyType, frequency, fmt.Errorf("invalid period type %q", periodType)
+	}
+	if period != 0 && !containsInt(rules.periods, period) {
+		return periodType, frequencyType, frequency, fmt.Errorf("period of %d is invalid for %s, must be one of %v", period, periodType, rules.periods)
+	}
+	if period != 0 && !start.IsZero() {
+		return periodType, frequencyType, frequency, fmt.Errorf("give either a period or a start date, not both")
+	}
+	if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+		return periodType, frequencyType, frequency, fmt.Errorf("start %v must be before end %v", start, end)
+	}
+
+	if frequencyType == "" {
+		frequencyType = rules.frequencies[0]
+	}
+	valid := false
+	for _, f := range rules.frequencies {
+		valid = valid || f == frequencyType
+	}
+	if !valid {
+		return periodType, frequencyType, frequency, fmt.Errorf("frequency type %s is invalid for %s, must be one of %v", frequencyType, periodType, rules.frequencies)
+	}
+
+	if frequency == 0 {
+		frequency = 1
+	}
+	if frequencyType == FrequencyMinute {
+		if !containsInt(minuteFrequencies, frequency) {
+			return periodType, frequencyType, frequency, fmt.Errorf("minute frequency of %d is invalid, must be one of %v", frequency, minuteFrequencies)
+		}
+	} else if frequency != 1 {
+		return periodType, frequencyType, frequency, fmt.Errorf("%s frequency must be 1", frequencyType)
+	}
+	return periodType, frequencyType, frequency, nil
+}
+
+// GetPriceHistory gets symbol's candles, oldest first.  Zero values take
+// TD's defaults: a periodType of day, the period type's usual frequency
+// type, a frequency of 1, and a period of 10 days, 1 month or 1 year.
+// Give either a period or a start date.  A zero end means now.
+//
+//	candles, err := c.GetPriceHistory("SPY", tdam.PeriodYear, 1, tdam.FrequencyDaily, 1, time.Time{}, time.Time{}, false)
+//
+// Minute bars over a long start/end range are fetched in several requests.
+func (c *Client) GetPriceHistory(symbol string, periodType PeriodType, period int, frequencyType FrequencyType, frequency int, start, end time.Time, extendedHours bool) ([]Candle, error) {
+	return c.GetPriceHistoryContext(context.Background(), symbol, periodType, period, frequencyType, frequency, start, end, extendedHours)
+}
+
+func (c *Client) GetPriceHistoryContext(ctx context.Context, symbol string, periodType PeriodType, period int, frequencyType FrequencyType, frequency int, start, end time.Time, extendedHours bool) ([]Candle, error) {
+	periodType, frequencyType, frequency, err := priceHistoryParams(periodType, period, frequencyType, frequency, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("invalid price history request: %v", err)
+	}
+
+	query := url.Values{}
+	query.Set("periodType", string(periodType))
+	query.Set("frequencyType", string(frequencyType))
+	query.Set("frequency", strconv.Itoa(frequency))
+	query.Set("needExtendedHoursData", strconv.FormatBool(extendedHours))
+	if period != 0 {
+		query.Set("period", strconv.Itoa(period))
+	}
+
+	if start.IsZero() || frequencyType != FrequencyMinute {
+		return c.priceHistory(ctx, symbol, query, start, end)
+	}
+
+	if end.IsZero() {
+		end = time.Now()
+	}
+	var candles []Candle
+	for from := start; from.Before(end); from = from.Add(minuteChunk) {
+		to := from.Add(minuteChunk)
+		if to.After(end) {
+			to = end
+		}
+		chunk, err := c.priceHistory(ctx, symbol, query, from, to)
+		if err != nil {
+			return nil, err
+		}
+		candles = append(candles, chunk...)
+	}
+	return dedupCandles(candles), nil
+}
+
+func (c *Client) priceHistory(ctx context.Context, symbol string, query url.Values, start, end time.Time) ([]Candle, error) {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	if !start.IsZero() {
+		q.Set("startDate", strconv.FormatInt(start.UnixNano()/int64(time.Millisecond), 10))
+	}
+	if !end.IsZero() {
+		q.Set("endDate", strconv.FormatInt(end.UnixNano()/int64(time.Millisecond), 10))
+	}
+
+	var resp priceHistoryResponse
+	if err := c.doMarketDataJSON(ctx, Request{
+		Endpoint: fmt.Sprintf("/v1/marketdata/%s/pricehistory", url.PathEscape(symbol)),
+		Query:    q,
+	}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Candles, nil
+}
+
+// sorts by time and drops repeats where chunks overlap
+func dedupCandles(candles []Candle) []Candle {
+	sort.SliceStable(candles, func(i, j int) bool {
+		return candles[i].Datetime < candles[j].Datetime
+	})
+	var out []Candle
+	for _, c := range candles {
+		if len(out) > 0 && c.Datetime == out[len(out)-1].Datetime {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
@@ -0,0 +1,97 @@
+package tdam_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ianmcmahon/tdam"
+	"github.com/ianmcmahon/tdam/tdamtest"
+)
+
+func TestPriceHistoryValidation(t *testing.T) {
+	srv := tdamtest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+
+	start := time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		periodType    tdam.PeriodType
+		period        int
+		frequencyType tdam.FrequencyType
+		frequency     int
+		start         time.Time
+		ok            bool
+	}{
+		{"", 0, "", 0, time.Time{}, true},
+		{tdam.PeriodDay, 5, tdam.FrequencyMinute, 15, time.Time{}, true},
+		{tdam.PeriodDay, 7, tdam.FrequencyMinute, 1, time.Time{}, false},
+		{tdam.PeriodDay, 1, tdam.FrequencyDaily, 1, time.Time{}, false},
+		{tdam.PeriodDay, 1, tdam.FrequencyMinute, 2, time.Time{}, false},
+		{tdam.PeriodMonth, 6, tdam.FrequencyDaily, 1, time.Time{}, true},
+		{tdam.PeriodMonth, 1, tdam.FrequencyMonthly, 1, time.Time{}, false},
+		{tdam.PeriodYear, 20, tdam.FrequencyMonthly, 1, time.Time{}, true},
+		{tdam.PeriodYear, 1, tdam.FrequencyWeekly, 5, time.Time{}, false},
+		{tdam.PeriodYTD, 1, tdam.FrequencyDaily, 1, time.Time{}, true},
+		{tdam.PeriodYTD, 2, tdam.FrequencyDaily, 1, time.Time{}, false},
+		{tdam.PeriodMonth, 1, tdam.FrequencyDaily, 1, start, false},
+		{tdam.PeriodMonth, 0, tdam.FrequencyDaily, 1, start, true},
+		{"week", 1, tdam.FrequencyDaily, 1, time.Time{}, false},
+	} {
+		_, err := c.GetPriceHistory("SPY", tc.periodType, tc.period, tc.frequencyType, tc.frequency, tc.start, time.Time{}, false)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s %d %s %d: got error %v", tc.periodType, tc.period, tc.frequencyType, tc.frequency, err)
+		}
+	}
+}
+
+func TestGetPriceHistoryChunks(t *testing.T) {
+	srv := tdamtest.NewServer()
+	defer srv.Close()
+
+	// hourly bars, which TD returns inclusive of the range's end so chunks overlap
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(25 * 24 * time.Hour)
+	srv.Update(func(st *tdamtest.State) {
+		for at := start.Add(-24 * time.Hour); !at.After(end.Add(24 * time.Hour)); at = at.Add(time.Hour) {
+			st.PriceHistory["SPY"] = append(st.PriceHistory["SPY"], tdam.Candle{
+				Open: tdam.Dollar, Close: tdam.Dollar, Volume: 100, Datetime: at.UnixNano() / int64(time.Millisecond),
+			})
+		}
+	})
+	requests := func() int {
+		n := 0
+		for _, r := range srv.Requests() {
+			if r == "GET /v1/marketdata/SPY/pricehistory" {
+				n++
+			}
+		}
+		return n
+	}
+
+	c := srv.Client()
+	candles, err := c.GetPriceHistory("SPY", tdam.PeriodDay, 0, tdam.FrequencyMinute, 30, start, end, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := requests(); n != 3 {
+		t.Errorf("expected 3 chunked requests, got %d", n)
+	}
+	if len(candles) != 25*24+1 {
+		t.Errorf("expected %d candles, got %d", 25*24+1, len(candles))
+	}
+	for i := 1; i < len(candles); i++ {
+		if candles[i].Datetime <= candles[i-1].Datetime {
+			t.Fatalf("candles out of order or repeated at %d: %v, %v", i, candles[i-1], candles[i])
+		}
+	}
+	if !candles[0].Time().Equal(start) || !candles[len(candles)-1].Time().Equal(end) {
+		t.Errorf("candles from %v to %v", candles[0].Time(), candles[len(candles)-1].Time())
+	}
+
+	if _, err := c.GetPriceHistory("SPY", tdam.PeriodYear, 1, tdam.FrequencyDaily, 1, time.Time{}, time.Time{}, false); err != nil {
+		t.Fatal(err)
+	}
+	if n := requests(); n != 4 {
+		t.Errorf("daily history shouldn't be chunked")
+	}
+}
@@ -0,0 +1,353 @@
+package tdam
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Quote is one of the *Quote types below, decoded according to its
+// assetType.  Asset types without their own fields decode as *QuoteInfo.
+type Quote interface {
+	Info() *QuoteInfo
+}
+
+// QuoteInfo is common to every kind of quote
+type QuoteInfo struct {
+	AssetType        InstrumentType `json:"assetType"`
+	AssetMainType    InstrumentType `json:"assetMainType,omitempty"`
+	Symbol           string         `json:"symbol"`
+	Description      string         `json:"description,omitempty"`
+	Cusip            string         `json:"cusip,omitempty"`
+	Exchange         string         `json:"exchange,omitempty"`
+	ExchangeName     string         `json:"exchangeName,omitempty"`
+	SecurityStatus   string         `json:"securityStatus,omitempty"`
+	QuoteTimeInLong  int64          `json:"quoteTimeInLong,omitempty"`
+	TradeTimeInLong  int64          `json:"tradeTimeInLong,omitempty"`
+	Delayed          bool           `json:"delayed"`
+	RealtimeEntitled bool           `json:"realtimeEntitled,omitempty"`
+}
+
+func (q *QuoteInfo) Info() *QuoteInfo { return q }
+
+func (q *QuoteInfo) QuoteTime() time.Time {
+	return millisToTime(q.QuoteTimeInLong)
+}
+
+func (q *QuoteInfo) TradeTime() time.Time {
+	return millisToTime(q.TradeTimeInLong)
+}
+
+func millisToTime(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond))
+}
+
+// EquityQuote is a stock or ETF quote
+type EquityQuote struct {
+	QuoteInfo
+	BidPrice                     Money   `json:"bidPrice"`
+	BidSize                      float64 `json:"bidSize"`
+	BidId                        string  `json:"bidId,omitempty"`
+	AskPrice                     Money   `json:"askPrice"`
+	AskSize                      float64 `json:"askSize"`
+	AskId                        string  `json:"askId,omitempty"`
+	LastPrice                    Money   `json:"lastPrice"`
+	LastSize                     float64 `json:"lastSize"`
+	LastId                       string  `json:"lastId,omitempty"`
+	OpenPrice                    Money   `json:"openPrice"`
+	HighPrice                    Money   `json:"highPrice"`
+	LowPrice                     Money   `json:"lowPrice"`
+	ClosePrice                   Money   `json:"closePrice"`
+	BidTick                      string  `json:"bidTick,omitempty"`
+	NetChange                    Money   `json:"netChange"`
+	TotalVolume                  int64   `json:"totalVolume"`
+	Mark                         Money   `json:"mark"`
+	Marginable                   bool    `json:"marginable"`
+	Shortable                    bool    `json:"shortable"`
+	Volatility                   float64 `json:"volatility"`
+	Digits                       int     `json:"digits"`
+	FiftyTwoWeekHigh             Money   `json:"52WkHigh"`
+	FiftyTwoWeekLow              Money   `json:"52WkLow"`
+	NAV                          float64 `json:"nAV"`
+	PeRatio                      float64 `json:"peRatio"`
+	DivAmount                    Money   `json:"divAmount"`
+	DivYield                     float64 `json:"divYield"`
+	DivDate                      string  `json:"divDate,omitempty"`
+	RegularMarketLastPrice       Money   `json:"regularMarketLastPrice"`
+	RegularMarketLastSize        float64 `json:"regularMarketLastSize"`
+	RegularMarketNetChange       Money   `json:"regularMarketNetChange"`
+	RegularMarketTradeTimeInLong int64   `json:"regularMarketTradeTimeInLong,omitempty"`
+	NetPercentChange             float64 `json:"netPercentChangeInDouble"`
+	MarkChange                   Money   `json:"markChangeInDouble"`
+	MarkPercentChange            float64 `json:"markPercentChangeInDouble"`
+	RegularMarketPercentChange   float64 `json:"regularMarketPercentChangeInDouble"`
+}
+
+type OptionQuote struct {
+	QuoteInfo
+	BidPrice               Money               `json:"bidPrice"`
+	BidSize                float64             `json:"bidSize"`
+	AskPrice               Money               `json:"askPrice"`
+	AskSize                float64             `json:"askSize"`
+	LastPrice              Money               `json:"lastPrice"`
+	LastSize               float64             `json:"lastSize"`
+	OpenPrice              Money               `json:"openPrice"`
+	HighPrice              Money               `json:"highPrice"`
+	LowPrice               Money               `json:"lowPrice"`
+	ClosePrice             Money               `json:"closePrice"`
+	NetChange              Money               `json:"netChange"`
+	TotalVolume            int64               `json:"totalVolume"`
+	Mark                   Money               `json:"mark"`
+	OpenInterest           float64             `json:"openInterest"`
+	Volatility             float64             `json:"volatility"`
+	MoneyIntrinsicValue    Money               `json:"moneyIntrinsicValue"`
+	Multiplier             float64             `json:"multiplier"`
+	Digits                 int                 `json:"digits"`
+	StrikePrice            float64             `json:"strikePrice"`
+	ContractType           string              `json:"contractType"` // 'P' or 'C'
+	Underlying             string              `json:"underlying"`
+	ExpirationDay          int                 `json:"expirationDay"`
+	ExpirationMonth        int                 `json:"expirationMonth"`
+	ExpirationYear         int                 `json:"expirationYear"`
+	DaysToExpiration       int                 `json:"daysToExpiration"`
+	TimeValue              Money               `json:"timeValue"`
+	Deliverables           string              `json:"deliverables,omitempty"`
+	Delta                  float64             `json:"delta"`
+	Gamma                  float64             `json:"gamma"`
+	Theta                  float64             `json:"theta"`
+	Vega                   float64             `json:"vega"`
+	Rho                    float64             `json:"rho"`
+	TheoreticalOptionValue Money               `json:"theoreticalOptionValue"`
+	UnderlyingPrice        Money               `json:"underlyingPrice"`
+	UvExpirationType       string              `json:"uvExpirationType,omitempty"`
+	LastTradingDay         int64               `json:"lastTradingDay,omitempty"`
+	SettlementType         string              `json:"settlementType,omitempty"`
+	NetPercentChange       float64             `json:"netPercentChangeInDouble"`
+	MarkChange             Money               `json:"markChangeInDouble"`
+	MarkPercentChange      float64             `json:"markPercentChangeInDouble"`
+	ImpliedYield           float64             `json:"impliedYield"`
+	IsPennyPilot           bool                `json:"isPennyPilot"`
+	OptionDeliverables     []OptionDeliverable `json:"optionDeliverablesList,omitempty"`
+}
+
+// PutCall is the option's ContractType
+func (q *OptionQuote) PutCall() ContractType {
+	if q.ContractType == "P" {
+		return PUT
+	}
+	return CALL
+}
+
+// Expiration is the option's expiration date, in UTC
+func (q *OptionQuote) Expiration() time.Time {
+	return time.Date(q.ExpirationYear, time.Month(q.ExpirationMonth), q.ExpirationDay, 0, 0, 0, 0, time.UTC)
+}
+
+type IndexQuote struct {
+	QuoteInfo
+	LastPrice        Money   `json:"lastPrice"`
+	OpenPrice        Money   `json:"openPrice"`
+	HighPrice        Money   `json:"highPrice"`
+	LowPrice         Money   `json:"lowPrice"`
+	ClosePrice       Money   `json:"closePrice"`
+	NetChange        Money   `json:"netChange"`
+	TotalVolume      int64   `json:"totalVolume"`
+	Digits           int     `json:"digits"`
+	FiftyTwoWeekHigh Money   `json:"52WkHigh"`
+	FiftyTwoWeekLow  Money   `json:"52WkLow"`
+	NetPercentChange float64 `json:"netPercentChangeInDouble"`
+}
+
+type MutualFundQuote struct {
+	QuoteInfo
+	ClosePrice       Money   `json:"closePrice"`
+	NetChange        Money   `json:"netChange"`
+	TotalVolume      int64   `json:"totalVolume"`
+	Digits           int     `json:"digits"`
+	FiftyTwoWeekHigh Money   `json:"52WkHigh"`
+	FiftyTwoWeekLow  Money   `json:"52WkLow"`
+	NAV              Money   `json:"nAV"`
+	PeRatio          float64 `json:"peRatio"`
+	DivAmount        Money   `json:"divAmount"`
+	DivYield         float64 `json:"divYield"`
+	DivDate          string  `json:"divDate,omitempty"`
+	NetPercentChange float64 `json:"netPercentChangeInDouble"`
+}
+
+// FutureQuote prices are floats, since futures tick in fractions like
+// 1/32 that Money can't hold exactly
+type FutureQuote struct {
+	QuoteInfo
+	BidPrice             float64 `json:"bidPriceInDouble"`
+	BidSize              int64   `json:"bidSizeInLong"`
+	BidId                string  `json:"bidId,omitempty"`
+	AskPrice             float64 `json:"askPriceInDouble"`
+	AskSize              int64   `json:"askSizeInLong"`
+	AskId                string  `json:"askId,omitempty"`
+	LastPrice            float64 `json:"lastPriceInDouble"`
+	LastSize             int64   `json:"lastSizeInLong"`
+	LastId               string  `json:"lastId,omitempty"`
+	OpenPrice            float64 `json:"openPriceInDouble"`
+	HighPrice            float64 `json:"highPriceInDouble"`
+	LowPrice             float64 `json:"lowPriceInDouble"`
+	ClosePrice           float64 `json:"closePriceInDouble"`
+	Change               float64 `json:"changeInDouble"`
+	PercentChange        float64 `json:"futurePercentChange"`
+	TotalVolume          int64   `json:"totalVolume"`
+	OpenInterest         int64   `json:"openInterest"`
+	Mark                 float64 `json:"mark"`
+	Tick                 float64 `json:"tick"`
+	TickAmount           float64 `json:"tickAmount"`
+	Product              string  `json:"product"`
+	PriceFormat          string  `json:"futurePriceFormat"`
+	TradingHours         string  `json:"futureTradingHours"`
+	IsTradable           bool    `json:"futureIsTradable"`
+	Multiplier           float64 `json:"futureMultiplier"`
+	IsActive             bool    `json:"futureIsActive"`
+	SettlementPrice      float64 `json:"futureSettlementPrice"`
+	ActiveSymbol         string  `json:"futureActiveSymbol"`
+	ExpirationDateInLong int64   `json:"futureExpirationDate"`
+}
+
+// ForexQuote prices are floats, currency pairs quote past four decimals
+type ForexQuote struct {
+	QuoteInfo
+	BidPrice         float64 `json:"bidPriceInDouble"`
+	BidSize          float64 `json:"bidSize"`
+	AskPrice         float64 `json:"askPriceInDouble"`
+	AskSize          float64 `json:"askSize"`
+	LastPrice        float64 `json:"lastPriceInDouble"`
+	LastSize         float64 `json:"lastSize"`
+	OpenPrice        float64 `json:"openPriceInDouble"`
+	HighPrice        float64 `json:"highPriceInDouble"`
+	LowPrice         float64 `json:"lowPriceInDouble"`
+	ClosePrice       float64 `json:"closePriceInDouble"`
+	Change           float64 `json:"changeInDouble"`
+	PercentChange    float64 `json:"percentChange"`
+	TotalVolume      int64   `json:"totalVolume"`
+	Digits           int     `json:"digits"`
+	Tick             float64 `json:"tick"`
+	TickAmount       float64 `json:"tickAmount"`
+	Product          string  `json:"product"`
+	TradingHours     string  `json:"tradingHours"`
+	IsTradable       bool    `json:"isTradable"`
+	MarketMaker      string  `json:"marketMaker"`
+	FiftyTwoWeekHigh float64 `json:"52WkHighInDouble"`
+	FiftyTwoWeekLow  float64 `json:"52WkLowInDouble"`
+	Mark             float64 `json:"mark"`
+}
+
+// UnmarshalQuote decodes a quote into the type for its assetType
+func UnmarshalQuote(b []byte) (Quote, error) {
+	var info QuoteInfo
+	if err := json.Unmarshal(b, &info); err != nil {
+		return nil, err
+	}
+
+	var q Quote
+	switch info.AssetType {
+	case EQUITY, ETF:
+		q = &EquityQuote{}
+	case OPTION:
+		q = &OptionQuote{}
+	case INDEX:
+		q = &IndexQuote{}
+	case MUTUAL_FUND:
+		q = &MutualFundQuote{}
+	case FUTURE:
+		q = &FutureQuote{}
+	case FOREX:
+		q = &ForexQuote{}
+	default:
+		return &info, nil
+	}
+	if err := json.Unmarshal(b, q); err != nil {
+		return nil, fmt.Errorf("decoding %s quote: %v", info.AssetType, err)
+	}
+	return q, nil
+}
+
+// GetQuote gets one symbol's quote, see GetQuotes
+func (c *Client) GetQuote(symbol string) (Quote, error) {
+	return c.GetQuoteContext(context.Background(), symbol)
+}
+
+func (c *Client) GetQuoteContext(ctx context.Context, symbol string) (Quote, error) {
+	quotes, err := c.GetQuotesContext(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	q, ok := quotes[symbol]
+	if !ok {
+		return nil, fmt.Errorf("no quote for %s", symbol)
+	}
+	return q, nil
+}
+
+// GetQuotes gets quotes for symbols in one request, keyed by symbol.
+// Symbols TD doesn't know are left out.  Quotes are real time if the
+// client has a token TD accepts, otherwise they're requested with just the
+// consumer key and come back delayed.
+func (c *Client) GetQuotes(symbols ...string) (map[string]Quote, error) {
+	return c.GetQuotesContext(context.Background(), symbols...)
+}
+
+func (c *Client) GetQuotesContext(ctx context.Context, symbols ...string) (map[string]Quote, error) {
+	if len(symbols) == 0 {
+		return map[string]Quote{}, nil
+	}
+	var raw map[string]json.RawMessage
+	if err := c.doMarketDataJSON(ctx, Request{
+		Endpoint: "/v1/marketdata/quotes",
+		Query:    map[string][]string{"symbol": {strings.Join(symbols, ",")}},
+	}, &raw); err != nil {
+		return nil, err
+	}
+
+	quotes := make(map[string]Quote, len(raw))
+	for symbol, b := range raw {
+		q, err := UnmarshalQuote(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", symbol, err)
+		}
+		quotes[symbol] = q
+	}
+	return quotes, nil
+}
+
+// doMarketDataJSON sends a market data request, which TD serves with or
+// without a token.  It's authenticated if the client has a token, and sent
+// again with just the consumer key if that token turns out to be stale: it
+// can't be refreshed or TD rejects it.
+func (c *Client) doMarketDataJSON(ctx context.Context, r Request, out interface{}) error {
+	if !c.hasToken() {
+		return c.DoJSONContext(ctx, r, out)
+	}
+	r.Authenticated = true
+	err := c.DoJSONContext(ctx, r, out)
+	if err == nil || ctx.Err() != nil || !staleToken(err) {
+		return err
+	}
+	r.Authenticated = false
+	return c.DoJSONContext(ctx, r, out)
+}
+
+func staleToken(err error) bool {
+	var refreshErr *TokenRefreshError
+	return errors.As(err, &refreshErr) || errors.Is(err, ErrNoToken) || IsUnauthorized(err)
+}
+
+// hasToken is true if requests can be authenticated without a login
+func (c *Client) hasToken() bool {
+	token, err := c.loadToken()
+	if err != nil || token == nil {
+		return false
+	}
+	return token.RefreshToken != "" || time.Now().Before(token.AccessExpiry)
+}
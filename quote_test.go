@@ -0,0 +1,160 @@
+package tdam_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ianmcmahon/tdam"
+	"github.com/ianmcmahon/tdam/tdamtest"
+)
+
+// loadQuotes decodes testdata/quotes_response.json, one quote per asset type
+func loadQuotes(t *testing.T) map[string]tdam.Quote {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "quotes_response.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		t.Fatal(err)
+	}
+	quotes := map[string]tdam.Quote{}
+	for symbol, r := range raw {
+		q, err := tdam.UnmarshalQuote(r)
+		if err != nil {
+			t.Fatalf("%s: %v", symbol, err)
+		}
+		quotes[symbol] = q
+	}
+	return quotes
+}
+
+func TestUnmarshalQuote(t *testing.T) {
+	quotes := loadQuotes(t)
+
+	aapl, ok := quotes["AAPL"].(*tdam.EquityQuote)
+	if !ok {
+		t.Fatalf("AAPL decoded as %T", quotes["AAPL"])
+	}
+	if aapl.LastPrice != tdam.Dollars(124.61) || aapl.FiftyTwoWeekLow != tdam.Dollars(53.1525) || !aapl.Shortable {
+		t.Errorf("unexpected equity quote %+v", aapl)
+	}
+	if want := time.Date(2021, 3, 12, 20, 59, 59, 995e6, time.UTC); !aapl.TradeTime().Equal(want) {
+		t.Errorf("trade time %v, want %v", aapl.TradeTime(), want)
+	}
+
+	put, ok := quotes["SPY_031921P390"].(*tdam.OptionQuote)
+	if !ok {
+		t.Fatalf("option decoded as %T", quotes["SPY_031921P390"])
+	}
+	if put.PutCall() != tdam.PUT || put.Mark != tdam.Dollars(3.575) || put.Delta != -0.4014 {
+		t.Errorf("unexpected option quote %+v", put)
+	}
+	if want := time.Date(2021, 3, 19, 0, 0, 0, 0, time.UTC); !put.Expiration().Equal(want) {
+		t.Errorf("expiration %v", put.Expiration())
+	}
+
+	if spx, ok := quotes["$SPX.X"].(*tdam.IndexQuote); !ok || spx.LastPrice != tdam.Dollars(3943.34) {
+		t.Errorf("unexpected index quote %#v", quotes["$SPX.X"])
+	}
+	if fund, ok := quotes["VFIAX"].(*tdam.MutualFundQuote); !ok || fund.NAV != tdam.Dollars(362.47) {
+		t.Errorf("unexpected mutual fund quote %#v", quotes["VFIAX"])
+	}
+	if zn, ok := quotes["/ZN"].(*tdam.FutureQuote); !ok || zn.BidPrice != 131.984375 || zn.ActiveSymbol != "/ZNM21" {
+		t.Errorf("unexpected future quote %#v", quotes["/ZN"])
+	}
+	if eur, ok := quotes["EUR/USD"].(*tdam.ForexQuote); !ok || eur.BidPrice != 1.19521 {
+		t.Errorf("unexpected forex quote %#v", quotes["EUR/USD"])
+	}
+
+	// asset types we don't know come back as the common fields
+	q, err := tdam.UnmarshalQuote([]byte(`{"assetType":"BOND","symbol":"912810SS8"}`))
+	if info, ok := q.(*tdam.QuoteInfo); err != nil || !ok || info.Symbol != "912810SS8" {
+		t.Errorf("unexpected fallback quote %#v, %v", q, err)
+	}
+}
+
+func TestGetQuotes(t *testing.T) {
+	srv := tdamtest.NewServer()
+	defer srv.Close()
+	quotes := loadQuotes(t)
+	srv.Update(func(st *tdamtest.State) {
+		for symbol, q := range quotes {
+			st.Quotes[symbol] = q
+		}
+	})
+
+	c := srv.Client()
+	got, err := c.GetQuotes("AAPL", "SPY_031921P390", "$SPX.X", "VFIAX", "/ZN", "EUR/USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(quotes) {
+		t.Errorf("got %d quotes, want %d", len(got), len(quotes))
+	}
+	for symbol, q := range got {
+		if reflect.TypeOf(q) != reflect.TypeOf(quotes[symbol]) || q.Info().Delayed {
+			t.Errorf("%s: got a %T, delayed %v", symbol, q, q.Info().Delayed)
+		}
+	}
+	if reqs := srv.Requests(); reqs[len(reqs)-1] != "GET /v1/marketdata/quotes" {
+		t.Errorf("unexpected request %s", reqs[len(reqs)-1])
+	}
+
+	// just the consumer key
+	c = srv.Client()
+	c.TokenStore = tdam.NewMemoryTokenStore()
+	quote, err := c.GetQuote("SPY_031921P390")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if put, ok := quote.(*tdam.OptionQuote); !ok || !put.Delayed || put.Mark != tdam.Dollars(3.575) {
+		t.Errorf("unexpected unauthenticated quote %#v", quote)
+	}
+
+	if _, err := c.GetQuote("NOPE"); err == nil {
+		t.Errorf("expected an error for a missing quote")
+	}
+}
+
+func TestGetQuoteWithStaleToken(t *testing.T) {
+	srv := tdamtest.NewServer()
+	defer srv.Close()
+	quotes := loadQuotes(t)
+	srv.Update(func(st *tdamtest.State) {
+		st.Quotes["AAPL"] = quotes["AAPL"]
+	})
+
+	// a revoked access token is rejected by the quotes endpoint
+	revoked := srv.Client()
+	srv.AccessToken = "rotated"
+
+	// an expired access token whose refresh token is no good either
+	expired := srv.Client()
+	expired.TokenStore.SaveToken(&tdam.TokenResponse{
+		AccessToken:   "expired",
+		AccessExpiry:  time.Now().Add(-time.Minute),
+		RefreshToken:  "revoked",
+		RefreshExpiry: time.Now().Add(time.Hour),
+	})
+
+	for name, c := range map[string]*tdam.Client{"revoked": revoked, "expired": expired} {
+		quote, err := c.GetQuote("AAPL")
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !quote.Info().Delayed {
+			t.Errorf("%s: expected a delayed quote with the consumer key", name)
+		}
+	}
+
+	// calls that need the token still fail
+	if _, err := revoked.GetAccounts(); !tdam.IsUnauthorized(err) {
+		t.Errorf("expected unauthorized, got %v", err)
+	}
+}
@@ -0,0 +1,212 @@
+package tdam
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TD allows 120 requests per minute per consumer key, across all endpoints
+const DefaultRequestsPerMinute = 120
+
+type RateLimitMode int
+
+const (
+	// block until a request slot is available (or the context is done)
+	RateLimitBlock RateLimitMode = iota
+	// return ErrRateLimited immediately when no slot is available
+	RateLimitFailFast
+)
+
+var ErrRateLimited = errors.New("client-side rate limit exceeded")
+
+type bucket struct {
+	capacity float64
+	tokens   float64
+	perSec   float64
+	last     time.Time
+}
+
+func newBucket(perMinute int) *bucket {
+	return &bucket{
+		capacity: float64(perMinute),
+		tokens:   float64(perMinute),
+		perSec:   float64(perMinute) / 60.0,
+		last:     time.Now(),
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.perSec
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+}
+
+// time until one token is available
+func (b *bucket) delay() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.perSec * float64(time.Second))
+}
+
+// RateLimiter is a token bucket shared by every request a Client makes.
+// Every request draws from the global budget, and additionally from the
+// budget of the longest endpoint prefix registered with SetEndpointLimit.
+type RateLimiter struct {
+	Mode RateLimitMode
+
+	mu        sync.Mutex
+	global    *bucket
+	endpoints map[string]*bucket
+	waiting   int32
+}
+
+func NewRateLimiter(perMinute int) *RateLimiter {
+	return &RateLimiter{
+		global:    newBucket(perMinute),
+		endpoints: make(map[string]*bucket),
+	}
+}
+
+// SetEndpointLimit gives endpoints starting with prefix their own budget,
+// eg "/v1/accounts/" for order placement.
+func (l *RateLimiter) SetEndpointLimit(prefix string, perMinute int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.endpoints[prefix] = newBucket(perMinute)
+}
+
+// QueueDepth is the number of requests currently blocked waiting for a slot.
+func (l *RateLimiter) QueueDepth() int {
+	return int(atomic.LoadInt32(&l.waiting))
+}
+
+func (l *RateLimiter) endpointBucket(endpoint string) *bucket {
+	var match *bucket
+	matchLen := -1
+	for prefix, b := range l.endpoints {
+		if strings.HasPrefix(endpoint, prefix) && len(prefix) > matchLen {
+			match, matchLen = b, len(prefix)
+		}
+	}
+	return match
+}
+
+// reserve takes a slot if one is available, otherwise reports how long to wait
+func (l *RateLimiter) reserve(endpoint string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	buckets := []*bucket{l.global}
+	if b := l.endpointBucket(endpoint); b != nil {
+		buckets = append(buckets, b)
+	}
+
+	var wait time.Duration
+	for _, b := range buckets {
+		b.refill(now)
+		if d := b.delay(); d > wait {
+			wait = d
+		}
+	}
+	if wait > 0 {
+		return wait
+	}
+	for _, b := range buckets {
+		b.tokens--
+	}
+	return 0
+}
+
+// Wait blocks until a request to endpoint may be sent.
+func (l *RateLimiter) Wait(ctx context.Context, endpoint string) error {
+	if l == nil {
+		return nil
+	}
+
+	wait := l.reserve(endpoint)
+	if wait == 0 {
+		return nil
+	}
+	if l.Mode == RateLimitFailFast {
+		return ErrRateLimited
+	}
+
+	atomic.AddInt32(&l.waiting, 1)
+	defer atomic.AddInt32(&l.waiting, -1)
+
+	for wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		wait = l.reserve(endpoint)
+	}
+	return nil
+}
+
+// retryable reports whether a response status is worth another attempt.
+// 5xx is only retried for GET, so we never double-submit an order.
+func retryable(method string, status int) bool {
+	if status == http.StatusTooManyRequests {
+		return true
+	}
+	return status >= 500 && method == "GET"
+}
+
+func (c *Client) backoff(attempt int, resp *http.Response) time.Duration {
+	if d := parseRetryAfter(resp.Header.Get("Retry-After")); d > 0 {
+		return d
+	}
+	base := c.RetryBackoff
+	if base <= 0 {
+		base = 500 * time.Millisecond
+	}
+	return base << uint(attempt)
+}
+
+// send runs newReq through the rate limiter, retrying 429s and 5xx with backoff
+func (c *Client) send(ctx context.Context, endpoint string, newReq func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := c.RateLimiter.Wait(ctx, endpoint); err != nil {
+			return nil, err
+		}
+
+		req, err := newReq()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.httpClient().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if attempt >= c.MaxRetries || !retryable(req.Method, resp.StatusCode) {
+			return resp, nil
+		}
+
+		delay := c.backoff(attempt, resp)
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package tdam
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterFailFast(t *testing.T) {
+	l := NewRateLimiter(2)
+	l.Mode = RateLimitFailFast
+
+	for i := 0; i < 2; i++ {
+		if err := l.Wait(context.Background(), "/v1/accounts"); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+	if err := l.Wait(context.Background(), "/v1/accounts"); !IsRateLimited(err) {
+		t.Errorf("expected rate limit error, got %v", err)
+	}
+}
+
+func TestRateLimiterEndpointBudget(t *testing.T) {
+	l := NewRateLimiter(120)
+	l.Mode = RateLimitFailFast
+	l.SetEndpointLimit("/v1/accounts/123/orders", 1)
+
+	if err := l.Wait(context.Background(), "/v1/accounts/123/orders"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Wait(context.Background(), "/v1/accounts/123/orders"); err != ErrRateLimited {
+		t.Errorf("expected endpoint budget to be exhausted, got %v", err)
+	}
+	if err := l.Wait(context.Background(), "/v1/marketdata/chains"); err != nil {
+		t.Errorf("other endpoints should still have budget: %v", err)
+	}
+}
+
+// failingServer answers the first failures requests with status, then 200,
+// and records when each request arrived
+func failingServer(failures, status int, retryAfter string) (*httptest.Server, *[]time.Time) {
+	var times []time.Time
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		n := len(times)
+		mu.Unlock()
+		if n <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	return srv, &times
+}
+
+func retryClient(url string, maxRetries int, backoff time.Duration) *Client {
+	c := NewClient("KEY")
+	c.APIBaseURL = url
+	c.RateLimiter = nil
+	c.MaxRetries = maxRetries
+	c.RetryBackoff = backoff
+	return c
+}
+
+func TestRetries(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		method     string
+		status     int
+		failures   int
+		maxRetries int
+		requests   int
+		ok         bool
+	}{
+		{"429 until it succeeds", "GET", 429, 2, 3, 3, true},
+		{"429 on a POST", "POST", 429, 1, 3, 2, true},
+		{"GET 5xx until it succeeds", "GET", 503, 3, 3, 4, true},
+		{"GET 5xx past MaxRetries", "GET", 500, 5, 2, 3, false},
+		{"POST 5xx isn't retried", "POST", 500, 1, 3, 1, false},
+		{"DELETE 5xx isn't retried", "DELETE", 502, 1, 3, 1, false},
+		{"4xx isn't retried", "GET", 400, 1, 3, 1, false},
+		{"no retries", "GET", 429, 1, 0, 1, false},
+	} {
+		srv, times := failingServer(tc.failures, tc.status, "")
+		c := retryClient(srv.URL, tc.maxRetries, time.Millisecond)
+		err := c.DoJSON(Request{Method: tc.method, Endpoint: "/v1/accounts/123/orders"}, nil)
+		srv.Close()
+
+		if len(*times) != tc.requests {
+			t.Errorf("%s: %d requests, want %d", tc.name, len(*times), tc.requests)
+		}
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: got error %v", tc.name, err)
+		}
+		if err != nil && statusOf(err) != tc.status {
+			t.Errorf("%s: expected the last %d, got %v", tc.name, tc.status, err)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	// doubles per attempt
+	srv, times := failingServer(3, 503, "")
+	defer srv.Close()
+	if err := retryClient(srv.URL, 3, 20*time.Millisecond).DoJSON(Request{Endpoint: "/v1/accounts"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	ts := *times
+	for i, want := range []time.Duration{20, 40, 80} {
+		if gap := ts[i+1].Sub(ts[i]); gap < want*time.Millisecond {
+			t.Errorf("attempt %d waited %v, want at least %vms", i+1, gap, want)
+		}
+	}
+
+	// Retry-After wins over the backoff, either way
+	srv, times = failingServer(1, 429, "1")
+	defer srv.Close()
+	if err := retryClient(srv.URL, 3, time.Millisecond).DoJSON(Request{Endpoint: "/v1/accounts"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if gap := (*times)[1].Sub((*times)[0]); gap < 900*time.Millisecond {
+		t.Errorf("waited %v, want Retry-After's 1s", gap)
+	}
+
+	srv, times = failingServer(1, 429, "0")
+	defer srv.Close()
+	start := time.Now()
+	if err := retryClient(srv.URL, 3, 50*time.Millisecond).DoJSON(Request{Endpoint: "/v1/accounts"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if gap := time.Since(start); gap < 50*time.Millisecond {
+		t.Errorf("a Retry-After of 0 should fall back to the backoff, waited %v", gap)
+	}
+}
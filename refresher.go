@@ -0,0 +1,208 @@
+package tdam
+
+import (
+	"context"
+	"time"
+)
+
+type TokenEventType int
+
+const (
+	TokenRefreshed TokenEventType = iota
+	RefreshTokenRotated
+	TokenRefreshFailed
+	RefreshTokenExpiring
+)
+
+func (t TokenEventType) String() string {
+	switch t {
+	case TokenRefreshed:
+		return "refreshed"
+	case RefreshTokenRotated:
+		return "refresh token rotated"
+	case TokenRefreshFailed:
+		return "refresh failed"
+	case RefreshTokenExpiring:
+		return "refresh token expiring"
+	default:
+		return "unknown"
+	}
+}
+
+type TokenEvent struct {
+	Type  TokenEventType
+	Token *TokenResponse
+	Err   error // set for TokenRefreshFailed
+
+	// time left on the refresh token, set for RefreshTokenExpiring
+	RefreshExpiresIn time.Duration
+}
+
+// DaysLeft is RefreshExpiresIn in whole days
+func (e TokenEvent) DaysLeft() int {
+	return int(e.RefreshExpiresIn / (24 * time.Hour))
+}
+
+type RefresherOptions struct {
+	// refresh the access token this long before it expires.  Default 5 minutes
+	AccessLeeway time.Duration
+
+	// rotate the refresh token once it's within this long of expiring.
+	// Default 30 days
+	RotateBefore time.Duration
+
+	// emit RefreshTokenExpiring, at most once a day, once the refresh
+	// token is within this long of expiring.  Default 14 days
+	WarnBefore time.Duration
+
+	// how long to wait after a failed refresh.  Default 1 minute
+	RetryInterval time.Duration
+
+	// called from the refresher goroutine for every event
+	OnEvent func(TokenEvent)
+
+	// if set, events are also sent here, dropped if the channel is full
+	Events chan<- TokenEvent
+}
+
+func (o *RefresherOptions) setDefaults() {
+	if o.AccessLeeway <= 0 {
+		o.AccessLeeway = 5 * time.Minute
+	}
+	if o.RotateBefore <= 0 {
+		o.RotateBefore = 30 * 24 * time.Hour
+	}
+	if o.WarnBefore <= 0 {
+		o.WarnBefore = 14 * 24 * time.Hour
+	}
+	if o.RetryInterval <= 0 {
+		o.RetryInterval = time.Minute
+	}
+}
+
+func (o *RefresherOptions) emit(e TokenEvent) {
+	if o.OnEvent != nil {
+		o.OnEvent(e)
+	}
+	if o.Events != nil {
+		select {
+		case o.Events <- e:
+		default:
+		}
+	}
+}
+
+// StartTokenRefresher keeps the client's tokens fresh in the background
+// until ctx is done or the returned stop func is called.  Refreshed tokens
+// are saved to the client's TokenStore.
+func (c *Client) StartTokenRefresher(ctx context.Context, opts RefresherOptions) (stop func()) {
+	opts.setDefaults()
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		var lastWarning time.Time
+
+		for {
+			wait := c.refreshStep(ctx, &opts, &lastWarning)
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func (c *Client) loadToken() (*TokenResponse, error) {
+	if t := c.currentToken(); t != nil {
+		return t, nil
+	}
+	store, err := c.tokenStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.LoadToken()
+}
+
+// refreshStep does whatever refreshing is due and returns how long to sleep
+func (c *Client) refreshStep(ctx context.Context, opts *RefresherOptions, lastWarning *time.Time) time.Duration {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	token, err := c.loadToken()
+	if err == nil && (token == nil || token.RefreshToken == "") {
+		err = ErrNoToken
+	}
+	if err != nil {
+		opts.emit(TokenEvent{Type: TokenRefreshFailed, Err: err})
+		return opts.RetryInterval
+	}
+
+	now := time.Now()
+	hasRefreshExpiry := !token.RefreshExpiry.IsZero()
+
+	switch {
+	case hasRefreshExpiry && token.RefreshExpiry.Sub(now) < opts.RotateBefore:
+		newToken, err := c.rotateRefreshToken(ctx, token.RefreshToken)
+		if err == nil {
+			newToken.keepRefreshToken(token)
+			err = c.SetToken(newToken)
+		}
+		if err != nil {
+			opts.emit(TokenEvent{Type: TokenRefreshFailed, Token: token, Err: err})
+			return c.warnExpiring(opts, token, now, lastWarning, opts.RetryInterval)
+		}
+		token = c.currentToken()
+		opts.emit(TokenEvent{Type: RefreshTokenRotated, Token: token})
+
+	case token.AccessExpiry.Sub(now) < opts.AccessLeeway:
+		newToken, err := c.refreshToken(ctx, token.RefreshToken)
+		if err == nil {
+			newToken.keepRefreshToken(token)
+			err = c.SetToken(newToken)
+		}
+		if err != nil {
+			opts.emit(TokenEvent{Type: TokenRefreshFailed, Token: token, Err: err})
+			return c.warnExpiring(opts, token, now, lastWarning, opts.RetryInterval)
+		}
+		token = c.currentToken()
+		opts.emit(TokenEvent{Type: TokenRefreshed, Token: token})
+	}
+
+	wait := token.AccessExpiry.Sub(now) - opts.AccessLeeway
+	if hasRefreshExpiry {
+		if d := token.RefreshExpiry.Sub(now) - opts.RotateBefore; d < wait {
+			wait = d
+		}
+	}
+	return c.warnExpiring(opts, token, now, lastWarning, wait)
+}
+
+// emits RefreshTokenExpiring if due, and caps wait so the next warning isn't missed
+func (c *Client) warnExpiring(opts *RefresherOptions, token *TokenResponse, now time.Time, lastWarning *time.Time, wait time.Duration) time.Duration {
+	if !token.RefreshExpiry.IsZero() {
+		left := token.RefreshExpiry.Sub(now)
+		if left < opts.WarnBefore {
+			if now.Sub(*lastWarning) >= 24*time.Hour {
+				*lastWarning = now
+				opts.emit(TokenEvent{Type: RefreshTokenExpiring, Token: token, RefreshExpiresIn: left})
+			}
+			if wait > 24*time.Hour {
+				wait = 24 * time.Hour
+			}
+		}
+	}
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return wait
+}
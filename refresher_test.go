@@ -0,0 +1,87 @@
+package tdam
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenRefresher(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		if req.Form.Get("access_type") == "offline" {
+			fmt.Fprintf(w, `{"access_token":"rotated","refresh_token":"new-refresh","expires_in":1800,"refresh_token_expires_in":7776000}`)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token":"refreshed","expires_in":1800}`)
+	}))
+	defer tokenServer.Close()
+
+	c := NewClient("KEY")
+	c.TokenURL = tokenServer.URL
+	c.TokenStore = NewMemoryTokenStore()
+	c.TokenStore.SaveToken(&TokenResponse{
+		AccessToken:   "stale",
+		AccessExpiry:  time.Now().Add(-time.Minute),
+		RefreshToken:  "refresh",
+		RefreshExpiry: time.Now().Add(60 * 24 * time.Hour),
+	})
+
+	events := make(chan TokenEvent, 10)
+	stop := c.StartTokenRefresher(context.Background(), RefresherOptions{Events: events})
+	defer stop()
+
+	select {
+	case e := <-events:
+		if e.Type != TokenRefreshed || e.Token.AccessToken != "refreshed" {
+			t.Fatalf("unexpected event %v: %#v", e.Type, e)
+		}
+		if e.Token.RefreshToken != "refresh" {
+			t.Errorf("refresh token should be kept, got %q", e.Token.RefreshToken)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no refresh event")
+	}
+}
+
+func TestTokenRefresherRotatesAndWarns(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintf(w, `{"error":"invalid_grant"}`)
+	}))
+	defer tokenServer.Close()
+
+	c := NewClient("KEY")
+	c.TokenURL = tokenServer.URL
+	c.MaxRetries = 0
+	c.TokenStore = NewMemoryTokenStore()
+	c.TokenStore.SaveToken(&TokenResponse{
+		AccessToken:   "fresh",
+		AccessExpiry:  time.Now().Add(time.Hour),
+		RefreshToken:  "refresh",
+		RefreshExpiry: time.Now().Add(3 * 24 * time.Hour),
+	})
+
+	var got []TokenEvent
+	events := make(chan TokenEvent, 10)
+	stop := c.StartTokenRefresher(context.Background(), RefresherOptions{Events: events})
+	for len(got) < 2 {
+		select {
+		case e := <-events:
+			got = append(got, e)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected 2 events, got %d", len(got))
+		}
+	}
+	stop()
+
+	if got[0].Type != TokenRefreshFailed || !IsUnauthorized(got[0].Err) {
+		t.Errorf("expected failed rotation, got %v %v", got[0].Type, got[0].Err)
+	}
+	if got[1].Type != RefreshTokenExpiring || got[1].DaysLeft() != 2 {
+		t.Errorf("expected expiry warning with 2 days left, got %v %d", got[1].Type, got[1].DaysLeft())
+	}
+}
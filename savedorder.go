@@ -0,0 +1,79 @@
+package tdam
+
+import (
+	"context"
+	"fmt"
+)
+
+// Saved orders show up in thinkorswim for someone to review and send
+// by hand.  They're regular Orders, with SavedOrderId and SavedTime set.
+
+func (a *Account) savedOrdersEndpoint() string {
+	return fmt.Sprintf("/v1/accounts/%s/savedorders", a.AccountId)
+}
+
+// SaveOrder saves order without sending it, returning the saved order's id
+func (a *Account) SaveOrder(order Order) (int, error) {
+	return a.SaveOrderContext(context.Background(), order)
+}
+
+func (a *Account) SaveOrderContext(ctx context.Context, order Order) (int, error) {
+	return a.submitOrder(ctx, "POST", a.savedOrdersEndpoint(), order)
+}
+
+func (a *Account) GetSavedOrders() ([]Order, error) {
+	return a.GetSavedOrdersContext(context.Background())
+}
+
+func (a *Account) GetSavedOrdersContext(ctx context.Context) ([]Order, error) {
+	var orders []Order
+	if err := a.DoJSONContext(ctx, Request{
+		Endpoint:      a.savedOrdersEndpoint(),
+		Authenticated: true,
+	}, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (a *Account) GetSavedOrder(savedOrderID int) (*Order, error) {
+	return a.GetSavedOrderContext(context.Background(), savedOrderID)
+}
+
+func (a *Account) GetSavedOrderContext(ctx context.Context, savedOrderID int) (*Order, error) {
+	var order Order
+	if err := a.DoJSONContext(ctx, Request{
+		Endpoint:      fmt.Sprintf("%s/%d", a.savedOrdersEndpoint(), savedOrderID),
+		Authenticated: true,
+	}, &order); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// ReplaceSavedOrder overwrites a saved order.  Unlike ReplaceOrder the
+// saved order keeps its id.
+func (a *Account) ReplaceSavedOrder(savedOrderID int, order Order) error {
+	return a.ReplaceSavedOrderContext(context.Background(), savedOrderID, order)
+}
+
+func (a *Account) ReplaceSavedOrderContext(ctx context.Context, savedOrderID int, order Order) error {
+	return a.DoJSONContext(ctx, Request{
+		Method:        "PUT",
+		Endpoint:      fmt.Sprintf("%s/%d", a.savedOrdersEndpoint(), savedOrderID),
+		Body:          order,
+		Authenticated: true,
+	}, nil)
+}
+
+func (a *Account) DeleteSavedOrder(savedOrderID int) error {
+	return a.DeleteSavedOrderContext(context.Background(), savedOrderID)
+}
+
+func (a *Account) DeleteSavedOrderContext(ctx context.Context, savedOrderID int) error {
+	return a.DoJSONContext(ctx, Request{
+		Method:        "DELETE",
+		Endpoint:      fmt.Sprintf("%s/%d", a.savedOrdersEndpoint(), savedOrderID),
+		Authenticated: true,
+	}, nil)
+}
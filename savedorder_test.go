@@ -0,0 +1,137 @@
+package tdam_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ianmcmahon/tdam"
+	"github.com/ianmcmahon/tdam/tdamtest"
+)
+
+func TestSavedOrders(t *testing.T) {
+	srv := tdamtest.NewServer()
+	defer srv.Close()
+
+	accounts, err := srv.Client().GetAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := accounts[0]
+
+	order, err := tdam.Equity("SPY").Buy(1).Limit(400).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := a.SaveOrder(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := a.GetSavedOrders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved[0].SavedOrderId != id || saved[0].SavedTime == nil {
+		t.Fatalf("unexpected saved orders: %#v", saved)
+	}
+	if orders, _ := a.GetOrders(tdam.OrderFilter{}); len(orders) != 0 {
+		t.Errorf("saving shouldn't place an order, got %#v", orders)
+	}
+
+	order.Price = tdam.Dollars(399)
+	if err := a.ReplaceSavedOrder(id, order); err != nil {
+		t.Fatal(err)
+	}
+	replaced, err := a.GetSavedOrder(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replaced.Price != tdam.Dollars(399) {
+		t.Errorf("expected replaced price 399, got %s", replaced.Price)
+	}
+
+	if err := a.DeleteSavedOrder(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.GetSavedOrder(id); !tdam.IsNotFound(err) {
+		t.Errorf("expected not found after delete, got %v", err)
+	}
+}
+
+// a chain with one expiration 45 days out and XYZ at 101, where the 100/95
+// put spread collects more than half its width
+func stagingChain(exp time.Time) []byte {
+	key := fmt.Sprintf("%s:45", exp.Format("2006-01-02"))
+	put := func(strike int, bid float64) string {
+		return fmt.Sprintf(`"%d.0":[{"putCall":"PUT","symbol":"XYZ_%sP%d","bid":%g,"ask":%g,"delta":-0.4,"strikePrice":%d}]`,
+			strike, exp.Format("010206"), strike, bid, bid+0.1, strike)
+	}
+	call := func(strike int) string {
+		return fmt.Sprintf(`"%d.0":[{"putCall":"CALL","symbol":"XYZ_%sC%d","strikePrice":%d}]`,
+			strike, exp.Format("010206"), strike, strike)
+	}
+	return []byte(fmt.Sprintf(`{"symbol":"XYZ","status":"SUCCESS","underlyingPrice":101,
+		"putExpDateMap":{%q:{%s,%s,%s,%s}},
+		"callExpDateMap":{%q:{%s,%s,%s,%s}}}`,
+		key, put(90, 0.1), put(95, 0.4), put(100, 3.02), put(105, 6),
+		key, call(90), call(95), call(100), call(105)))
+}
+
+func TestCoinflipStagesCandidates(t *testing.T) {
+	srv := tdamtest.NewServer()
+	defer srv.Close()
+	exp := time.Now().AddDate(0, 0, 45)
+	srv.Update(func(st *tdamtest.State) {
+		st.Chains["XYZ"] = stagingChain(exp)
+	})
+
+	c := srv.Client()
+	accounts, err := c.GetAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := accounts[0]
+
+	scanner := &tdam.Scanner{Client: c, Authenticated: true, Stage: a}
+	if _, err := scanner.Coinflip("XYZ", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := a.GetSavedOrders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 {
+		t.Fatalf("expected one staged order, got %#v", saved)
+	}
+	order := saved[0]
+	// the natural credit, 3.02 bid less 0.50 ask, rounded down to a nickel
+	if order.OrderType != tdam.OrderTypeNetCredit || order.Price != tdam.Dollars(2.50) || len(order.OrderLegCollection) != 2 {
+		t.Errorf("unexpected staged order %#v", order)
+	}
+	legs := map[string]tdam.Instruction{}
+	for _, leg := range order.OrderLegCollection {
+		legs[leg.Instrument.Info().Symbol] = leg.Instruction
+	}
+	date := exp.Format("010206")
+	if legs["XYZ_"+date+"P95"] != tdam.InstructionBuyToOpen || legs["XYZ_"+date+"P100"] != tdam.InstructionSellToOpen {
+		t.Errorf("expected to buy the 95 put and sell the 100 put, got %v", legs)
+	}
+
+	if orders, _ := a.GetOrders(tdam.OrderFilter{}); len(orders) != 0 {
+		t.Errorf("staging shouldn't place an order, got %#v", orders)
+	}
+
+	scanner.Ticks = tdam.PennyNickel
+	if _, err := scanner.Coinflip("XYZ", 1); err != nil {
+		t.Fatal(err)
+	}
+	saved, err = a.GetSavedOrders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 2 || saved[1].Price != tdam.Dollars(2.52) {
+		t.Errorf("expected a penny priced order, got %#v", saved)
+	}
+}
@@ -1,21 +1,29 @@
 package tdam
 
 import (
-	"crypto/tls"
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
 	"net/url"
 	"time"
+
+	"github.com/ianmcmahon/tdam/calendar"
 )
 
 type Scanner struct {
 	*Client
 	Authenticated bool
+
+	// if set, candidate trades are saved to this account for manual
+	// review in thinkorswim, rather than only printed
+	Stage *Account
+
+	// the scanned symbols' option price increments, staged orders are
+	// rounded down to them.  Defaults to NickelDime
+	Ticks TickRule
 }
 
 func DTE(min, max time.Duration) (from, to string) {
-	now := time.Now()
+	now := time.Now().In(calendar.NewYork)
 	near := now.Add(min * time.Hour * 24)
 	far := now.Add(max * time.Hour * 24)
 
@@ -24,7 +32,7 @@ func DTE(min, max time.Duration) (from, to string) {
 
 func (ch *OptionChain) divideTable(table StrikeTable) (puts, calls StrikeTable) {
 	for i, strike := range table {
-		if float64(strike.Price) > ch.UnderlyingPrice {
+		if strike.Price.Money() > ch.UnderlyingPrice {
 			puts, calls = table[:i], table[i:]
 			//sort.Reverse(byPrice(puts))
 			return
@@ -67,53 +75,59 @@ func (s *Scanner) Coinflip(symbol string, strikeWidth int) (*OptionChain, error)
 		longStrike := puts[len(puts)-1-strikeWidth]
 		spreadWidth := float64(shortStrike.Price - longStrike.Price)
 		credit := shortStrike.Put[0].BidPrice - longStrike.Put[0].BidPrice
-		if credit/spreadWidth > 0.5 {
-			fmt.Printf("%s %s PUT %.1f | %.2fΔ cr %.2f/%.1f (%.1f)%%\n", symbol, exp,
+		if credit.Float64()/spreadWidth > 0.5 {
+			fmt.Printf("%s %s PUT %.1f | %.2fΔ cr %s/%.1f (%.1f)%%\n", symbol, exp,
 				shortStrike.Price, shortStrike.Put[0].Delta, credit, spreadWidth,
-				credit/spreadWidth*100.0)
+				credit.Float64()/spreadWidth*100.0)
+
+			if s.Stage != nil {
+				if err := s.stage(longStrike, shortStrike); err != nil {
+					return chain, err
+				}
+			}
 		}
 	}
 
 	return chain, nil
 }
 
-func (s *Scanner) GetChain(symbol string, options url.Values) (*OptionChain, error) {
-	transport := &http.Transport{TLSClientConfig: &tls.Config{}}
-	client := &http.Client{Transport: transport}
-
-	req, err := http.NewRequest("GET", "https://api.tdameritrade.com/v1/marketdata/chains", nil)
+// saves a put credit spread for review, priced at the natural credit: the
+// short put's bid less the long put's ask, rounded down to a valid tick
+func (s *Scanner) stage(long, short Strike) error {
+	spread, err := PutVertical(long, short)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if options == nil {
-		options = req.URL.Query()
+	credit := short.Put[0].BidPrice - long.Put[0].AskPrice
+	credit = credit.RoundDown(s.Ticks.Tick(credit))
+	if credit <= 0 {
+		return fmt.Errorf("no natural credit for the %s/%s put spread", long.Price, short.Price)
 	}
-	if s.Authenticated {
-		token, err := s.TDAMToken()
-		if err != nil {
-			return nil, err
-		}
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-	} else {
-		options.Set("apikey", s.ConsumerKey)
+	order, err := spread.Open(1, credit.Float64()).Build()
+	if err != nil {
+		return err
 	}
-	options.Set("symbol", symbol)
-	req.URL.RawQuery = options.Encode()
+	_, err = s.Stage.SaveOrder(order)
+	return err
+}
 
-	//dump, _ := httputil.DumpRequest(req, false)
-	//fmt.Println(string(dump))
+func (s *Scanner) GetChain(symbol string, options url.Values) (*OptionChain, error) {
+	return s.GetChainContext(context.Background(), symbol, options)
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Printf("%v\n", err)
-		return nil, err
-	}
-	if resp.StatusCode != 200 {
-		fmt.Printf("status %d: %s\n", resp.StatusCode, resp.Status)
+func (s *Scanner) GetChainContext(ctx context.Context, symbol string, options url.Values) (*OptionChain, error) {
+	query := url.Values{}
+	for k, v := range options {
+		query[k] = v
 	}
+	query.Set("symbol", symbol)
 
 	var chain OptionChain
-	if err := json.NewDecoder(resp.Body).Decode(&chain); err != nil {
+	if err := s.DoJSONContext(ctx, Request{
+		Endpoint:      "/v1/marketdata/chains",
+		Query:         query,
+		Authenticated: s.Authenticated,
+	}, &chain); err != nil {
 		return nil, err
 	}
 
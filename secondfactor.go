@@ -0,0 +1,140 @@
+package tdam
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+type SecondFactor string
+
+const (
+	SecurityQuestion SecondFactor = "secretquestion"
+	SMSCode          SecondFactor = "smscode"
+)
+
+// SecondFactorProvider answers the second factor challenge during the
+// automatic login flow.
+type SecondFactorProvider interface {
+	// the factors this provider can answer, in order of preference
+	Factors() []SecondFactor
+	// Answer returns the response to prompt, which is the security question
+	// for SecurityQuestion, or the page's instructions for SMSCode
+	Answer(factor SecondFactor, prompt string) (string, error)
+}
+
+func normalizeQuestion(q string) string {
+	return strings.ToLower(strings.TrimSpace(html.UnescapeString(q)))
+}
+
+// SecurityQuestions maps security questions to their answers.
+// Questions are matched case-insensitively, ignoring html escaping.
+type SecurityQuestions map[string]string
+
+// LoadSecurityQuestions reads a json object of question: answer pairs
+func LoadSecurityQuestions(path string) (SecurityQuestions, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var sq SecurityQuestions
+	if err := json.Unmarshal(b, &sq); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", path, err)
+	}
+	return sq, nil
+}
+
+// EnvSecurityQuestions maps the four questions we've been asked so far
+// to TDAM_SQ_ANSWER_1 through TDAM_SQ_ANSWER_4.
+func EnvSecurityQuestions() SecurityQuestions {
+	sq := SecurityQuestions{}
+	for i, q := range []string{
+		"What is your paternal grandmother's first name?",
+		"What is your maternal grandmother's first name?",
+		"What was the name of the town your grandmother lived in?",
+		"What is your best friend's first name?",
+	} {
+		if answer := os.Getenv(fmt.Sprintf("TDAM_SQ_ANSWER_%d", i+1)); answer != "" {
+			sq[q] = answer
+		}
+	}
+	return sq
+}
+
+func (sq SecurityQuestions) Factors() []SecondFactor {
+	return []SecondFactor{SecurityQuestion}
+}
+
+func (sq SecurityQuestions) Answer(factor SecondFactor, prompt string) (string, error) {
+	if factor != SecurityQuestion {
+		return "", fmt.Errorf("unsupported second factor %s", factor)
+	}
+	want := normalizeQuestion(prompt)
+	for q, answer := range sq {
+		if q := normalizeQuestion(q); q != "" && strings.Contains(want, q) {
+			return answer, nil
+		}
+	}
+	return "", fmt.Errorf("no answer configured for security question %q", html.UnescapeString(prompt))
+}
+
+// SMSCodeFunc supplies the code texted to the user, eg by reading it from
+// an sms gateway or asking a human through some other channel.
+type SMSCodeFunc func(prompt string) (string, error)
+
+func (f SMSCodeFunc) Factors() []SecondFactor {
+	return []SecondFactor{SMSCode}
+}
+
+func (f SMSCodeFunc) Answer(factor SecondFactor, prompt string) (string, error) {
+	if factor != SMSCode {
+		return "", fmt.Errorf("unsupported second factor %s", factor)
+	}
+	return f(prompt)
+}
+
+// StdinPrompt asks the user at the terminal.
+type StdinPrompt struct {
+	In  io.Reader // defaults to os.Stdin
+	Out io.Writer // defaults to os.Stdout
+
+	reader *bufio.Reader
+}
+
+func (p *StdinPrompt) Factors() []SecondFactor {
+	return []SecondFactor{SecurityQuestion, SMSCode}
+}
+
+func (p *StdinPrompt) Answer(factor SecondFactor, prompt string) (string, error) {
+	if p.reader == nil {
+		in := p.In
+		if in == nil {
+			in = os.Stdin
+		}
+		p.reader = bufio.NewReader(in)
+	}
+	out := p.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	switch factor {
+	case SecurityQuestion:
+		fmt.Fprintf(out, "%s\n> ", html.UnescapeString(prompt))
+	case SMSCode:
+		fmt.Fprintf(out, "Enter the code sent by text message: ")
+	default:
+		return "", fmt.Errorf("unsupported second factor %s", factor)
+	}
+
+	line, err := p.reader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,182 @@
+package tdam
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const DefaultLoginTTL = 10 * time.Minute
+
+type pendingLogin struct {
+	userID  string
+	expires time.Time
+}
+
+// SessionManager keeps one token set, and one *Client, per user of a
+// multi-user app.  Each login attempt is issued an oauth state, which
+// AuthHandler uses to route the callback back to the user who started it.
+type SessionManager struct {
+	// per-user clients copy their urls, http client, rate limiter and second
+	// factor provider from Base.  The rate limiter is shared since TD's quota
+	// is per consumer key.  Defaults to NewClient("").
+	Base *Client
+
+	// creates the token store for a user.  Defaults to in-memory stores.
+	NewTokenStore func(userID string) TokenStore
+
+	// how long an issued state remains valid.  Defaults to DefaultLoginTTL
+	LoginTTL time.Duration
+
+	// called after a user's token is stored by AuthHandler
+	OnLogin func(userID string, w http.ResponseWriter, req *http.Request)
+
+	mu      sync.Mutex
+	pending map[string]pendingLogin // by state
+	clients map[string]*Client      // by user id
+}
+
+func NewSessionManager(base *Client) *SessionManager {
+	return &SessionManager{
+		Base:    base,
+		pending: make(map[string]pendingLogin),
+		clients: make(map[string]*Client),
+	}
+}
+
+// fills in what a zero SessionManager lacks.  m.mu must be held.
+func (m *SessionManager) init() {
+	if m.Base == nil {
+		m.Base = NewClient("")
+	}
+	if m.pending == nil {
+		m.pending = make(map[string]pendingLogin)
+	}
+	if m.clients == nil {
+		m.clients = make(map[string]*Client)
+	}
+}
+
+// Client returns the client for userID, creating it if necessary.
+// Until the user has logged in, its requests fail with ErrNoToken.
+func (m *SessionManager) Client(userID string) *Client {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.init()
+	if c, ok := m.clients[userID]; ok {
+		return c
+	}
+
+	var store TokenStore
+	if m.NewTokenStore != nil {
+		store = m.NewTokenStore(userID)
+	} else {
+		store = NewMemoryTokenStore()
+	}
+
+	b := m.Base
+	c := &Client{
+		ConsumerKey:  b.ConsumerKey,
+		APIBaseURL:   b.APIBaseURL,
+		AuthBaseURL:  b.AuthBaseURL,
+		TokenURL:     b.TokenURL,
+		RedirectURI:  b.RedirectURI,
+		HTTPClient:   b.HTTPClient,
+		RateLimiter:  b.RateLimiter,
+		MaxRetries:   b.MaxRetries,
+		RetryBackoff: b.RetryBackoff,
+		SecondFactor: b.SecondFactor,
+		TokenStore:   store,
+		noLoginFlow:  true,
+	}
+	m.clients[userID] = c
+	return c
+}
+
+// LoginURL issues a new state for userID and returns the authorize url
+// to send them to.
+func (m *SessionManager) LoginURL(userID string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	state := hex.EncodeToString(b)
+
+	ttl := m.LoginTTL
+	if ttl <= 0 {
+		ttl = DefaultLoginTTL
+	}
+
+	m.mu.Lock()
+	m.init()
+	now := time.Now()
+	for s, p := range m.pending {
+		if now.After(p.expires) {
+			delete(m.pending, s)
+		}
+	}
+	m.pending[state] = pendingLogin{userID: userID, expires: now.Add(ttl)}
+	m.mu.Unlock()
+
+	return m.Client(userID).AuthURL(state), nil
+}
+
+// takes the user for state, consuming it
+func (m *SessionManager) claimState(state string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.pending[state]
+	if !ok {
+		return "", false
+	}
+	delete(m.pending, state)
+	if time.Now().After(p.expires) {
+		return "", false
+	}
+	return p.userID, true
+}
+
+// Logout forgets userID's client.  Tokens already saved to a persistent
+// TokenStore are left in place.
+func (m *SessionManager) Logout(userID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.clients, userID)
+}
+
+// AuthHandler is the oauth redirect uri handler for all users.
+func (m *SessionManager) AuthHandler(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+
+	userID, ok := m.claimState(query.Get("state"))
+	if !ok {
+		http.Error(w, "unknown or expired login state", http.StatusBadRequest)
+		return
+	}
+
+	c := m.Client(userID)
+	token, err := c.getToken(req.Context(), query.Get("code"))
+	if err != nil {
+		log.Printf("Error getting token for %s: %v\n", userID, err)
+		http.Error(w, fmt.Sprintf("error getting token: %v", err), http.StatusBadGateway)
+		return
+	}
+	if err := c.SetToken(token); err != nil {
+		log.Printf("error setting token for %s: %v\n", userID, err)
+		http.Error(w, fmt.Sprintf("error setting token: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if m.OnLogin != nil {
+		m.OnLogin(userID, w, req)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintf(w, "token acquired! have fun")
+}
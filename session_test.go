@@ -0,0 +1,79 @@
+package tdam
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestSessionManagerRoutesCallbackByState(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		fmt.Fprintf(w, `{"access_token":"access-%s","refresh_token":"refresh-%s","expires_in":1800,"refresh_token_expires_in":7776000}`,
+			req.Form.Get("code"), req.Form.Get("code"))
+	}))
+	defer tokenServer.Close()
+
+	base := NewClient("KEY")
+	base.TokenURL = tokenServer.URL
+	m := NewSessionManager(base)
+
+	aliceURL, err := m.LoginURL("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.LoginURL("bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(aliceURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := u.Query().Get("state")
+
+	w := httptest.NewRecorder()
+	m.AuthHandler(w, httptest.NewRequest("GET", "/auth?code=abc&state="+state, nil))
+	if w.Code != 200 {
+		t.Fatalf("callback failed: %d %s", w.Code, w.Body)
+	}
+
+	if token, err := m.Client("alice").TDAMToken(); err != nil || token != "access-abc" {
+		t.Errorf("alice's token = %q, %v", token, err)
+	}
+	if _, err := m.Client("bob").TDAMToken(); err != ErrNoToken {
+		t.Errorf("bob should not have a token yet, got %v", err)
+	}
+
+	// states are single use
+	w = httptest.NewRecorder()
+	m.AuthHandler(w, httptest.NewRequest("GET", "/auth?code=abc&state="+state, nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("replayed state should be rejected, got %d", w.Code)
+	}
+}
+
+func TestSessionManagerZeroValue(t *testing.T) {
+	var m SessionManager
+	c := m.Client("alice")
+	if c.ConsumerKey != "" || c.RetryBackoff != NewClient("").RetryBackoff {
+		t.Errorf("zero manager should use NewClient's settings, got %+v", c)
+	}
+	if _, err := c.TDAMToken(); err != ErrNoToken {
+		t.Errorf("new user should have no token, got %v", err)
+	}
+	if _, err := m.LoginURL("bob"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSessionManagerCopiesSecondFactor(t *testing.T) {
+	base := NewClient("KEY")
+	base.SecondFactor = SecurityQuestions{"q": "a"}
+	m := NewSessionManager(base)
+	if _, ok := m.Client("alice").SecondFactor.(SecurityQuestions); !ok {
+		t.Error("per-user client should have Base's SecondFactor")
+	}
+}
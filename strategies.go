@@ -0,0 +1,335 @@
+package tdam
+
+import "fmt"
+
+// SpreadLeg is one option in a Spread.  Ratio is the number of contracts
+// per spread, positive when long and negative when short.
+type SpreadLeg struct {
+	Symbol string
+	Ratio  int
+}
+
+// Spread is a multi-leg option position, built by the constructors below
+// from TD or OCC option symbols.  Open, Close and Roll turn it into orders:
+//
+//	spread, err := tdam.PutVertical(longStrike, shortStrike)
+//	order, err := spread.Open(1, 1.05).Build()
+//
+// The constructors build the long side of symmetric strategies (straddles,
+// butterflies, calendars), use Reverse for the short side.
+type Spread struct {
+	Strategy ComplexOrderStrategyType
+	Legs     []SpreadLeg
+	Debit    bool // opening the spread costs money, rather than collecting it
+}
+
+func parseSymbols(symbols ...string) ([]OptionSymbol, error) {
+	parsed := make([]OptionSymbol, len(symbols))
+	for i, s := range symbols {
+		o, err := ParseOptionSymbol(s)
+		if err != nil {
+			return nil, err
+		}
+		parsed[i] = o
+	}
+	for _, o := range parsed[1:] {
+		if o.Underlying != parsed[0].Underlying {
+			return nil, fmt.Errorf("legs have different underlyings: %s and %s", parsed[0].Underlying, o.Underlying)
+		}
+	}
+	return parsed, nil
+}
+
+func sameExpiration(legs ...OptionSymbol) bool {
+	for _, o := range legs[1:] {
+		if !o.Expiration.Equal(legs[0].Expiration) {
+			return false
+		}
+	}
+	return true
+}
+
+func samePutCall(legs ...OptionSymbol) bool {
+	for _, o := range legs[1:] {
+		if o.PutCall != legs[0].PutCall {
+			return false
+		}
+	}
+	return true
+}
+
+// Long is a single long option, mostly useful for rolling
+func Long(symbol string) (Spread, error) {
+	o, err := ParseOptionSymbol(symbol)
+	if err != nil {
+		return Spread{}, err
+	}
+	return Spread{
+		Strategy: ComplexNone,
+		Legs:     []SpreadLeg{{o.String(), 1}},
+		Debit:    true,
+	}, nil
+}
+
+// Short is a single short option
+func Short(symbol string) (Spread, error) {
+	s, err := Long(symbol)
+	return s.Reverse(), err
+}
+
+// Vertical buys long and sells short, same expiration and type.  Whether
+// it's a debit or credit spread follows from the strikes.
+func Vertical(long, short string) (Spread, error) {
+	legs, err := parseSymbols(long, short)
+	if err != nil {
+		return Spread{}, err
+	}
+	l, s := legs[0], legs[1]
+	if !sameExpiration(l, s) || !samePutCall(l, s) {
+		return Spread{}, fmt.Errorf("vertical legs must be the same type and expiration")
+	}
+	if l.Strike == s.Strike {
+		return Spread{}, fmt.Errorf("vertical legs must have different strikes")
+	}
+	debit := l.Strike < s.Strike // calls are worth more at lower strikes
+	if l.PutCall == PUT {
+		debit = l.Strike > s.Strike
+	}
+	return Spread{
+		Strategy: ComplexVertical,
+		Legs:     []SpreadLeg{{l.String(), 1}, {s.String(), -1}},
+		Debit:    debit,
+	}, nil
+}
+
+// PutVertical is a Vertical from the puts of two strikes in a StrikeTable
+func PutVertical(long, short Strike) (Spread, error) {
+	return Vertical(long.PutSymbol(), short.PutSymbol())
+}
+
+// CallVertical is a Vertical from the calls of two strikes in a StrikeTable
+func CallVertical(long, short Strike) (Spread, error) {
+	return Vertical(long.CallSymbol(), short.CallSymbol())
+}
+
+// IronCondor is a put vertical and a call vertical in the same expiration.
+// With the short strikes inside the long ones it's the usual credit
+// spread, with them outside it's a reverse iron condor for a debit.
+func IronCondor(longPut, shortPut, shortCall, longCall string) (Spread, error) {
+	puts, err := Vertical(longPut, shortPut)
+	if err != nil {
+		return Spread{}, err
+	}
+	calls, err := Vertical(longCall, shortCall)
+	if err != nil {
+		return Spread{}, err
+	}
+	legs, _ := parseSymbols(longPut, shortPut, shortCall, longCall)
+	if legs[0].PutCall != PUT || legs[3].PutCall != CALL {
+		return Spread{}, fmt.Errorf("iron condor needs a put vertical and a call vertical")
+	}
+	if !sameExpiration(legs...) {
+		return Spread{}, fmt.Errorf("iron condor legs must have the same expiration")
+	}
+	if puts.Debit != calls.Debit {
+		return Spread{}, fmt.Errorf("iron condor verticals must both be credit or both be debit spreads")
+	}
+	if legs[1].Strike > legs[2].Strike {
+		return Spread{}, fmt.Errorf("iron condor put strikes must be below the call strikes")
+	}
+	return Spread{
+		Strategy: ComplexIronCondor,
+		Legs:     append(puts.Legs, calls.Legs[1], calls.Legs[0]),
+		Debit:    puts.Debit,
+	}, nil
+}
+
+// Butterfly buys lower and upper and sells two of middle, all the same type
+// and expiration.  Wings of different widths make an unbalanced butterfly.
+func Butterfly(lower, middle, upper string) (Spread, error) {
+	legs, err := parseSymbols(lower, middle, upper)
+	if err != nil {
+		return Spread{}, err
+	}
+	if !sameExpiration(legs...) || !samePutCall(legs...) {
+		return Spread{}, fmt.Errorf("butterfly legs must be the same type and expiration")
+	}
+	if !(legs[0].Strike < legs[1].Strike && legs[1].Strike < legs[2].Strike) {
+		return Spread{}, fmt.Errorf("butterfly strikes must be in ascending order")
+	}
+	strategy := ComplexButterfly
+	if legs[1].Strike-legs[0].Strike != legs[2].Strike-legs[1].Strike {
+		strategy = ComplexUnbalancedButterfly
+	}
+	return Spread{
+		Strategy: strategy,
+		Legs:     []SpreadLeg{{legs[0].String(), 1}, {legs[1].String(), -2}, {legs[2].String(), 1}},
+		Debit:    true,
+	}, nil
+}
+
+// Calendar sells near and buys far, same strike and type
+func Calendar(near, far string) (Spread, error) {
+	legs, err := parseSymbols(near, far)
+	if err != nil {
+		return Spread{}, err
+	}
+	if !samePutCall(legs...) || legs[0].Strike != legs[1].Strike {
+		return Spread{}, fmt.Errorf("calendar legs must be the same type and strike")
+	}
+	if !legs[0].Expiration.Before(legs[1].Expiration) {
+		return Spread{}, fmt.Errorf("calendar near leg must expire before the far leg")
+	}
+	return Spread{
+		Strategy: ComplexCalendar,
+		Legs:     []SpreadLeg{{legs[0].String(), -1}, {legs[1].String(), 1}},
+		Debit:    true,
+	}, nil
+}
+
+// Diagonal sells short and buys long, the same type at different strikes
+// and expirations.  It's taken to be a debit when the long leg expires
+// later, as it almost always is.
+func Diagonal(short, long string) (Spread, error) {
+	legs, err := parseSymbols(short, long)
+	if err != nil {
+		return Spread{}, err
+	}
+	s, l := legs[0], legs[1]
+	if !samePutCall(s, l) {
+		return Spread{}, fmt.Errorf("diagonal legs must be the same type")
+	}
+	if s.Strike == l.Strike || s.Expiration.Equal(l.Expiration) {
+		return Spread{}, fmt.Errorf("diagonal legs must have different strikes and expirations")
+	}
+	return Spread{
+		Strategy: ComplexDiagonal,
+		Legs:     []SpreadLeg{{s.String(), -1}, {l.String(), 1}},
+		Debit:    l.Expiration.After(s.Expiration),
+	}, nil
+}
+
+// Straddle buys a put and a call at the same strike and expiration
+func Straddle(put, call string) (Spread, error) {
+	legs, err := parseSymbols(put, call)
+	if err != nil {
+		return Spread{}, err
+	}
+	if legs[0].PutCall != PUT || legs[1].PutCall != CALL {
+		return Spread{}, fmt.Errorf("straddle needs a put and a call")
+	}
+	if !sameExpiration(legs...) || legs[0].Strike != legs[1].Strike {
+		return Spread{}, fmt.Errorf("straddle legs must have the same strike and expiration")
+	}
+	return Spread{
+		Strategy: ComplexStraddle,
+		Legs:     []SpreadLeg{{legs[0].String(), 1}, {legs[1].String(), 1}},
+		Debit:    true,
+	}, nil
+}
+
+// StraddleAt is a Straddle from the put and call of a StrikeTable strike
+func StraddleAt(strike Strike) (Spread, error) {
+	return Straddle(strike.PutSymbol(), strike.CallSymbol())
+}
+
+// Strangle buys a put and a higher strike call in the same expiration
+func Strangle(put, call string) (Spread, error) {
+	legs, err := parseSymbols(put, call)
+	if err != nil {
+		return Spread{}, err
+	}
+	if legs[0].PutCall != PUT || legs[1].PutCall != CALL {
+		return Spread{}, fmt.Errorf("strangle needs a put and a call")
+	}
+	if !sameExpiration(legs...) {
+		return Spread{}, fmt.Errorf("strangle legs must have the same expiration")
+	}
+	if legs[0].Strike >= legs[1].Strike {
+		return Spread{}, fmt.Errorf("strangle put strike must be below the call strike")
+	}
+	return Spread{
+		Strategy: ComplexStrangle,
+		Legs:     []SpreadLeg{{legs[0].String(), 1}, {legs[1].String(), 1}},
+		Debit:    true,
+	}, nil
+}
+
+// Reverse swaps the long and short legs, eg for a short straddle
+func (s Spread) Reverse() Spread {
+	r := Spread{Strategy: s.Strategy, Debit: !s.Debit}
+	for _, leg := range s.Legs {
+		r.Legs = append(r.Legs, SpreadLeg{leg.Symbol, -leg.Ratio})
+	}
+	return r
+}
+
+func (s Spread) legs(b *OrderBuilder, quantity int, closing bool) *OrderBuilder {
+	for _, leg := range s.Legs {
+		b.Option(leg.Symbol)
+		switch {
+		case leg.Ratio > 0 && !closing:
+			b.BuyToOpen(leg.Ratio * quantity)
+		case leg.Ratio > 0:
+			b.SellToClose(leg.Ratio * quantity)
+		case !closing:
+			b.SellToOpen(-leg.Ratio * quantity)
+		default:
+			b.BuyToClose(-leg.Ratio * quantity)
+		}
+	}
+	return b
+}
+
+func (s Spread) order(quantity int, price float64, closing bool) *OrderBuilder {
+	b := s.legs(&OrderBuilder{}, quantity, closing).Quantity(quantity)
+	if len(s.Legs) == 1 {
+		return b.Limit(price)
+	}
+	b.Strategy(s.Strategy)
+	if s.Debit != closing {
+		return b.NetDebit(price)
+	}
+	return b.NetCredit(price)
+}
+
+// Open is an order opening quantity spreads at a net price per spread
+func (s Spread) Open(quantity int, price float64) *OrderBuilder {
+	return s.order(quantity, price, false)
+}
+
+// Close is an order closing quantity spreads at a net price per spread.
+// Closing a spread opened for a credit is a debit and vice versa.
+func (s Spread) Close(quantity int, price float64) *OrderBuilder {
+	return s.order(quantity, price, true)
+}
+
+// Roll closes quantity of s and opens the same quantity of to in one order.
+// net is per spread, positive for a credit and negative for a debit.
+func (s Spread) Roll(to Spread, quantity int, net float64) *OrderBuilder {
+	b := s.legs(&OrderBuilder{}, quantity, true)
+	to.legs(b, quantity, false).Quantity(quantity)
+
+	switch {
+	case s.Strategy == ComplexVertical && to.Strategy == ComplexVertical:
+		b.Strategy(ComplexVerticalRoll)
+	case len(s.Legs) == 1 && len(to.Legs) == 1:
+		from, _ := ParseOptionSymbol(s.Legs[0].Symbol)
+		next, _ := ParseOptionSymbol(to.Legs[0].Symbol)
+		if from.Strike == next.Strike {
+			b.Strategy(ComplexCalendar)
+		} else {
+			b.Strategy(ComplexDiagonal)
+		}
+	default:
+		b.Strategy(ComplexCustom)
+	}
+
+	switch {
+	case net > 0:
+		return b.NetCredit(net)
+	case net < 0:
+		return b.NetDebit(-net)
+	}
+	return b.NetZero()
+}
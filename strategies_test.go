@@ -0,0 +1,146 @@
+package tdam
+
+import "testing"
+
+func TestParseOptionSymbol(t *testing.T) {
+	for _, sym := range []string{"SPY_121820P302.5", "SPY   201218P00302500"} {
+		o, err := ParseOptionSymbol(sym)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if o.Underlying != "SPY" || o.PutCall != PUT || o.Strike != 302.5 || o.Expiration.Format("2006-01-02") != "2020-12-18" {
+			t.Errorf("%s: got %+v", sym, o)
+		}
+		if o.String() != "SPY_121820P302.5" || o.OCC() != "SPY   201218P00302500" {
+			t.Errorf("%s: formatted as %s and %s", sym, o, o.OCC())
+		}
+	}
+	if _, err := ParseOptionSymbol("SPY"); err == nil {
+		t.Errorf("expected error for equity symbol")
+	}
+
+	for _, pc := range []ContractType{"", "P"} {
+		o := OptionSymbol{Underlying: "SPY", PutCall: pc, Strike: 300}
+		if o.String() != "" || o.OCC() != "" {
+			t.Errorf("PutCall %q: expected empty symbols, got %q and %q", pc, o, o.OCC())
+		}
+	}
+}
+
+type legWant struct {
+	symbol      string
+	instruction Instruction
+	quantity    int
+}
+
+func checkSpreadOrder(t *testing.T, name string, b *OrderBuilder, orderType OrderType, strategy ComplexOrderStrategyType, legs ...legWant) {
+	t.Helper()
+	order, err := b.Build()
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	if order.OrderType != orderType || order.ComplexOrderStrategyType != strategy {
+		t.Errorf("%s: got %s %s, want %s %s", name, order.OrderType, order.ComplexOrderStrategyType, orderType, strategy)
+	}
+	if len(order.OrderLegCollection) != len(legs) {
+		t.Fatalf("%s: got %d legs, want %d", name, len(order.OrderLegCollection), len(legs))
+	}
+	for i, want := range legs {
+		leg := order.OrderLegCollection[i]
+		if leg.Instrument.Info().Symbol != want.symbol || leg.Instruction != want.instruction || leg.Quantity != float64(want.quantity) {
+			t.Errorf("%s leg %d: got %s %s %g, want %s %s %d", name, i, leg.Instrument.Info().Symbol, leg.Instruction, leg.Quantity,
+				want.symbol, want.instruction, want.quantity)
+		}
+	}
+}
+
+func TestSpreads(t *testing.T) {
+	putCredit, err := Vertical("SPY_121820P290", "SPY_121820P300")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSpreadOrder(t, "put credit open", putCredit.Open(2, 1.05), OrderTypeNetCredit, ComplexVertical,
+		legWant{"SPY_121820P290", InstructionBuyToOpen, 2},
+		legWant{"SPY_121820P300", InstructionSellToOpen, 2})
+	checkSpreadOrder(t, "put credit close", putCredit.Close(2, 0.10), OrderTypeNetDebit, ComplexVertical,
+		legWant{"SPY_121820P290", InstructionSellToClose, 2},
+		legWant{"SPY_121820P300", InstructionBuyToClose, 2})
+
+	callDebit, _ := Vertical("SPY_121820C300", "SPY_121820C310")
+	if !callDebit.Debit {
+		t.Errorf("long lower call vertical should be a debit")
+	}
+
+	condor, err := IronCondor("SPY_121820P280", "SPY_121820P290", "SPY_121820C320", "SPY_121820C330")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSpreadOrder(t, "iron condor", condor.Open(1, 2.5), OrderTypeNetCredit, ComplexIronCondor,
+		legWant{"SPY_121820P280", InstructionBuyToOpen, 1},
+		legWant{"SPY_121820P290", InstructionSellToOpen, 1},
+		legWant{"SPY_121820C320", InstructionSellToOpen, 1},
+		legWant{"SPY_121820C330", InstructionBuyToOpen, 1})
+
+	fly, _ := Butterfly("SPY_121820C300", "SPY_121820C305", "SPY_121820C310")
+	checkSpreadOrder(t, "butterfly", fly.Open(3, 0.5), OrderTypeNetDebit, ComplexButterfly,
+		legWant{"SPY_121820C300", InstructionBuyToOpen, 3},
+		legWant{"SPY_121820C305", InstructionSellToOpen, 6},
+		legWant{"SPY_121820C310", InstructionBuyToOpen, 3})
+
+	cal, _ := Calendar("SPY_121820C300", "SPY_011521C300")
+	checkSpreadOrder(t, "calendar", cal.Open(1, 1.5), OrderTypeNetDebit, ComplexCalendar,
+		legWant{"SPY_121820C300", InstructionSellToOpen, 1},
+		legWant{"SPY_011521C300", InstructionBuyToOpen, 1})
+
+	straddle, _ := Straddle("SPY_121820P300", "SPY_121820C300")
+	checkSpreadOrder(t, "short straddle", straddle.Reverse().Open(1, 12), OrderTypeNetCredit, ComplexStraddle,
+		legWant{"SPY_121820P300", InstructionSellToOpen, 1},
+		legWant{"SPY_121820C300", InstructionSellToOpen, 1})
+
+	next, _ := Vertical("SPY_011521P285", "SPY_011521P295")
+	checkSpreadOrder(t, "roll", putCredit.Roll(next, 1, 0.25), OrderTypeNetCredit, ComplexVerticalRoll,
+		legWant{"SPY_121820P290", InstructionSellToClose, 1},
+		legWant{"SPY_121820P300", InstructionBuyToClose, 1},
+		legWant{"SPY_011521P285", InstructionBuyToOpen, 1},
+		legWant{"SPY_011521P295", InstructionSellToOpen, 1})
+
+	short, _ := Short("SPY_121820P300")
+	nextShort, _ := Short("SPY_011521P295")
+	checkSpreadOrder(t, "single roll", short.Roll(nextShort, 1, -0.1), OrderTypeNetDebit, ComplexDiagonal,
+		legWant{"SPY_121820P300", InstructionBuyToClose, 1},
+		legWant{"SPY_011521P295", InstructionSellToOpen, 1})
+}
+
+func TestSpreadErrors(t *testing.T) {
+	for name, err := range map[string]error{
+		"mixed vertical":   second(Vertical("SPY_121820P290", "SPY_121820C300")),
+		"mixed underlying": second(Vertical("SPY_121820P290", "QQQ_121820P300")),
+		"inverted fly":     second(Butterfly("SPY_121820C310", "SPY_121820C305", "SPY_121820C300")),
+		"backwards cal":    second(Calendar("SPY_011521C300", "SPY_121820C300")),
+		"mixed condor":     second(IronCondor("SPY_121820P290", "SPY_121820P280", "SPY_121820C320", "SPY_121820C330")),
+		"strangle order":   second(Strangle("SPY_121820P310", "SPY_121820C300")),
+	} {
+		if err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func second(_ Spread, err error) error { return err }
+
+func TestVerticalFromStrikeTable(t *testing.T) {
+	table := StrikeTable{
+		{Price: 290, Put: []Option{{Symbol: "SPY_121820P290"}}, Call: []Option{{Symbol: "SPY_121820C290"}}},
+		{Price: 300, Put: []Option{{Symbol: "SPY_121820P300"}}, Call: []Option{{Symbol: "SPY_121820C300"}}},
+	}
+	spread, err := PutVertical(table[0], table[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spread.Debit || spread.Legs[1].Symbol != "SPY_121820P300" {
+		t.Errorf("unexpected spread %+v", spread)
+	}
+	if _, err := CallVertical(table[0], Strike{Price: 310}); err == nil {
+		t.Errorf("expected error for strike without a call")
+	}
+}
@@ -0,0 +1,279 @@
+package streamer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ianmcmahon/tdam"
+)
+
+// ActivityType is the kind of an ACCT_ACTIVITY message, from field 2
+type ActivityType string
+
+const (
+	ActivitySubscribed                ActivityType = "SUBSCRIBED"
+	ActivityError                     ActivityType = "ERROR"
+	ActivityBrokenTrade               ActivityType = "BrokenTrade"
+	ActivityManualExecution           ActivityType = "ManualExecution"
+	ActivityOrderActivation           ActivityType = "OrderActivation"
+	ActivityOrderCancelReplaceRequest ActivityType = "OrderCancelReplaceRequest"
+	ActivityOrderCancelRequest        ActivityType = "OrderCancelRequest"
+	ActivityOrderEntryRequest         ActivityType = "OrderEntryRequest"
+	ActivityOrderFill                 ActivityType = "OrderFill"
+	ActivityOrderPartialFill          ActivityType = "OrderPartialFill"
+	ActivityOrderRejection            ActivityType = "OrderRejection"
+	ActivityTooLateToCancel           ActivityType = "TooLateToCancel"
+	ActivityUROUT                     ActivityType = "UROUT" // the order is out, ie canceled
+)
+
+// AccountActivity is one ACCT_ACTIVITY message.  Message is nil for
+// SUBSCRIBED and ERROR, and for types we don't know how to decode.
+type AccountActivity struct {
+	Account string
+	Type    ActivityType
+	Raw     string // the XML from field 3
+	Message ActivityMessage
+}
+
+// ActivityMessage is one of the *Message types below
+type ActivityMessage interface {
+	OrderInfo() *OrderMessage
+}
+
+// OrderGroupID identifies the account an activity message is for
+type OrderGroupID struct {
+	Firm           string `xml:"Firm"`
+	Branch         string `xml:"Branch"`
+	ClientKey      string `xml:"ClientKey"`
+	AccountKey     string `xml:"AccountKey"`
+	SubAccountType string `xml:"SubAccountType"`
+	CDDomainID     string `xml:"CDDomainID"`
+}
+
+type Security struct {
+	CUSIP            string `xml:"CUSIP"`
+	Symbol           string `xml:"Symbol"`
+	SecurityType     string `xml:"SecurityType"` // eg "Common Stock", "Call Option"
+	SymbolUnderlying string `xml:"SymbolUnderlying"`
+}
+
+type OrderPricing struct {
+	Limit tdam.Money `xml:"Limit"`
+	Stop  tdam.Money `xml:"Stop"`
+	Bid   tdam.Money `xml:"Bid"`
+	Ask   tdam.Money `xml:"Ask"`
+}
+
+// ActivityOrder is the order as the activity messages describe it, which
+// isn't quite how the REST API does
+type ActivityOrder struct {
+	OrderKey             int64        `xml:"OrderKey"`
+	Security             Security     `xml:"Security"`
+	OrderPricing         OrderPricing `xml:"OrderPricing"`
+	OrderType            string       `xml:"OrderType"`
+	OrderDuration        string       `xml:"OrderDuration"`
+	OrderEnteredDateTime time.Time    `xml:"OrderEnteredDateTime"`
+	OrderInstructions    string       `xml:"OrderInstructions"`
+	OriginalQuantity     float64      `xml:"OriginalQuantity"`
+	AmountIndicator      string       `xml:"AmountIndicator"`
+	Discretionary        bool         `xml:"Discretionary"`
+	OrderSource          string       `xml:"OrderSource"`
+	Solicited            bool         `xml:"Solicited"`
+	MarketCode           string       `xml:"MarketCode"`
+	Capacity             string       `xml:"Capacity"`
+	EnteringDevice       string       `xml:"EnteringDevice"`
+}
+
+// OrderMessage has the fields every order activity message shares
+type OrderMessage struct {
+	OrderGroupID      OrderGroupID  `xml:"OrderGroupID"`
+	ActivityTimestamp time.Time     `xml:"ActivityTimestamp"`
+	Order             ActivityOrder `xml:"Order"`
+}
+
+func (m *OrderMessage) OrderInfo() *OrderMessage {
+	return m
+}
+
+// OrderID is the order's id, as used by the REST API
+func (m *OrderMessage) OrderID() int {
+	return int(m.Order.OrderKey)
+}
+
+type ExecutionInformation struct {
+	Type                  string     `xml:"Type"` // "Bought" or "Sold"
+	Timestamp             time.Time  `xml:"Timestamp"`
+	Quantity              float64    `xml:"Quantity"`
+	ExecutionPrice        tdam.Money `xml:"ExecutionPrice"`
+	AveragePriceIndicator bool       `xml:"AveragePriceIndicator"`
+	LeavesQuantity        float64    `xml:"LeavesQuantity"`
+	ID                    string     `xml:"ID"`
+	Exchange              string     `xml:"Exchange"`
+	BrokerId              string     `xml:"BrokerId"`
+}
+
+type OrderEntryRequestMessage struct {
+	OrderMessage
+	LastUpdated time.Time `xml:"LastUpdated"`
+}
+
+type OrderFillMessage struct {
+	OrderMessage
+	OrderCompletionCode  string               `xml:"OrderCompletionCode"`
+	ExecutionInformation ExecutionInformation `xml:"ExecutionInformation"`
+	TradeDate            string               `xml:"TradeDate"`
+}
+
+type OrderPartialFillMessage struct {
+	OrderFillMessage
+	RemainingQuantity float64 `xml:"RemainingQuantity"`
+}
+
+type ManualExecutionMessage struct {
+	OrderFillMessage
+}
+
+type BrokenTradeMessage struct {
+	OrderMessage
+	ExecutionInformation ExecutionInformation `xml:"ExecutionInformation"`
+	ErrorDescription     string               `xml:"ErrorDescription"`
+}
+
+type OrderActivationMessage struct {
+	OrderMessage
+	ActivationPrice tdam.Money `xml:"ActivationPrice"`
+}
+
+type OrderCancelRequestMessage struct {
+	OrderMessage
+	PendingCancelQuantity float64 `xml:"PendingCancelQuantity"`
+}
+
+type OrderCancelReplaceRequestMessage struct {
+	OrderMessage
+	PendingCancelQuantity float64 `xml:"PendingCancelQuantity"`
+	OriginalOrderId       int64   `xml:"OriginalOrderId"`
+}
+
+type OrderRejectionMessage struct {
+	OrderMessage
+	RejectCode   string `xml:"RejectCode"`
+	RejectReason string `xml:"RejectReason"`
+	ReportedBy   string `xml:"ReportedBy"`
+}
+
+type TooLateToCancelMessage struct {
+	OrderMessage
+}
+
+type UROUTMessage struct {
+	OrderMessage
+	CancelledQuantity float64 `xml:"CancelledQuantity"`
+	OrderDestination  string  `xml:"OrderDestination"`
+}
+
+// DecodeActivity decodes the XML of an ACCT_ACTIVITY message.  It returns
+// nil with no error for SUBSCRIBED, ERROR and unknown types.
+func DecodeActivity(typ ActivityType, data string) (ActivityMessage, error) {
+	var msg ActivityMessage
+	switch typ {
+	case ActivityBrokenTrade:
+		msg = &BrokenTradeMessage{}
+	case ActivityManualExecution:
+		msg = &ManualExecutionMessage{}
+	case ActivityOrderActivation:
+		msg = &OrderActivationMessage{}
+	case ActivityOrderCancelReplaceRequest:
+		msg = &OrderCancelReplaceRequestMessage{}
+	case ActivityOrderCancelRequest:
+		msg = &OrderCancelRequestMessage{}
+	case ActivityOrderEntryRequest:
+		msg = &OrderEntryRequestMessage{}
+	case ActivityOrderFill:
+		msg = &OrderFillMessage{}
+	case ActivityOrderPartialFill:
+		msg = &OrderPartialFillMessage{}
+	case ActivityOrderRejection:
+		msg = &OrderRejectionMessage{}
+	case ActivityTooLateToCancel:
+		msg = &TooLateToCancelMessage{}
+	case ActivityUROUT:
+		msg = &UROUTMessage{}
+	default:
+		return nil, nil
+	}
+	if err := xml.Unmarshal([]byte(data), msg); err != nil {
+		return nil, fmt.Errorf("decoding %s: %v", typ, err)
+	}
+	return msg, nil
+}
+
+// ParseAccountActivity decodes one packet of ACCT_ACTIVITY content
+func ParseAccountActivity(packet map[string]interface{}) (*AccountActivity, error) {
+	a := &AccountActivity{}
+	a.Account, _ = packet["1"].(string)
+	typ, _ := packet["2"].(string)
+	a.Type = ActivityType(typ)
+	a.Raw, _ = packet["3"].(string)
+
+	msg, err := DecodeActivity(a.Type, a.Raw)
+	if err != nil {
+		return a, err
+	}
+	a.Message = msg
+	return a, nil
+}
+
+type ActivityCallback func(activity *AccountActivity)
+
+// SubscribeOrderActivity is SubscribeAcctActivity with the messages
+// decoded.  Messages that fail to decode are logged and passed on with
+// only Raw set.
+func (s *Streamer) SubscribeOrderActivity(subscriber string, cb ActivityCallback) error {
+	return s.SubscribeAcctActivity(subscriber, func(key string, data Data) {
+		for _, packet := range data.Content {
+			activity, err := ParseAccountActivity(packet)
+			if err != nil {
+				log.Printf("error decoding account activity: %v", err)
+			}
+			cb(activity)
+		}
+	})
+}
+
+// TrackOrders has tracker refresh an order whenever there's activity on
+// it, rather than waiting for its next poll.  Start the tracker with
+// OrderTrackerOptions.Streaming set so it polls less often.
+func (s *Streamer) TrackOrders(subscriber string, tracker *tdam.OrderTracker) error {
+	return s.SubscribeOrderActivity(subscriber, func(activity *AccountActivity) {
+		switch {
+		case activity.Message != nil:
+			tracker.Notify(activity.Message.OrderInfo().OrderID())
+			if m, ok := activity.Message.(*OrderCancelReplaceRequestMessage); ok {
+				tracker.Notify(int(m.OriginalOrderId))
+			}
+		case activity.Type != ActivitySubscribed:
+			tracker.Notify(0)
+		}
+	})
+}
+
+func (a *AccountActivity) String() string {
+	if a.Message == nil {
+		return string(a.Type)
+	}
+	o := a.Message.OrderInfo()
+	s := fmt.Sprintf("%s order %d: %s %g %s", a.Type, o.OrderID(), o.Order.OrderInstructions,
+		o.Order.OriginalQuantity, o.Order.Security.Symbol)
+	switch m := a.Message.(type) {
+	case *OrderFillMessage:
+		s += fmt.Sprintf(" filled %g @ %s", m.ExecutionInformation.Quantity, m.ExecutionInformation.ExecutionPrice)
+	case *OrderPartialFillMessage:
+		s += fmt.Sprintf(" filled %g @ %s", m.ExecutionInformation.Quantity, m.ExecutionInformation.ExecutionPrice)
+	case *OrderRejectionMessage:
+		s += " rejected: " + m.RejectReason
+	}
+	return s
+}
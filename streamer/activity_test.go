@@ -0,0 +1,65 @@
+package streamer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ianmcmahon/tdam"
+)
+
+const fillXML = `<?xml version="1.0" encoding="UTF-8"?><OrderFillMessage xmlns="urn:xmlns:beb.ameritrade.com"><OrderGroupID><Firm>150</Firm><Branch>123</Branch><ClientKey>123456789</ClientKey><AccountKey>123456789</AccountKey><SubAccountType>Margin</SubAccountType><CDDomainID>A000000012345678</CDDomainID></OrderGroupID><ActivityTimestamp>2020-06-22T09:30:01.512-05:00</ActivityTimestamp><Order><OrderKey>3012345678</OrderKey><Security><CUSIP>0SPY..FJ00300000</CUSIP><Symbol>SPY_061920P300</Symbol><SecurityType>Put Option</SecurityType><SymbolUnderlying>SPY</SymbolUnderlying></Security><OrderPricing><Limit>1.05</Limit><Bid>1.03</Bid><Ask>1.07</Ask></OrderPricing><OrderType>Limit</OrderType><OrderDuration>Day</OrderDuration><OrderEnteredDateTime>2020-06-22T09:30:00.120-05:00</OrderEnteredDateTime><OrderInstructions>Sell</OrderInstructions><OriginalQuantity>2</OriginalQuantity><AmountIndicator>Contracts</AmountIndicator><Discretionary>false</Discretionary><OrderSource>Web</OrderSource><Solicited>false</Solicited><MarketCode>Normal</MarketCode><Capacity>Agency</Capacity><EnteringDevice>AA_test</EnteringDevice></Order><OrderCompletionCode>Normal Completion</OrderCompletionCode><ExecutionInformation><Type>Sold</Type><Timestamp>2020-06-22T09:30:01.500-05:00</Timestamp><Quantity>2</Quantity><ExecutionPrice>1.06</ExecutionPrice><AveragePriceIndicator>false</AveragePriceIndicator><LeavesQuantity>0</LeavesQuantity><ID>ABC123</ID><Exchange>C</Exchange><BrokerId>XYZ</BrokerId></ExecutionInformation><TradeDate>2020-06-22</TradeDate></OrderFillMessage>`
+
+const replaceXML = `<?xml version="1.0" encoding="UTF-8"?><OrderCancelReplaceRequestMessage xmlns="urn:xmlns:beb.ameritrade.com"><OrderGroupID><AccountKey>123456789</AccountKey></OrderGroupID><ActivityTimestamp>2020-06-22T09:31:00-05:00</ActivityTimestamp><Order><OrderKey>3012345679</OrderKey><Security><Symbol>SPY</Symbol><SecurityType>Common Stock</SecurityType></Security><OrderPricing><Limit>300.5</Limit></OrderPricing><OrderType>Limit</OrderType><OrderEnteredDateTime>2020-06-22T09:31:00-05:00</OrderEnteredDateTime><OrderInstructions>Buy</OrderInstructions><OriginalQuantity>10</OriginalQuantity></Order><PendingCancelQuantity>10</PendingCancelQuantity><OriginalOrderId>3012345600</OriginalOrderId></OrderCancelReplaceRequestMessage>`
+
+func TestParseAccountActivity(t *testing.T) {
+	activity, err := ParseAccountActivity(map[string]interface{}{
+		"seq": 3.0,
+		"key": "subscription-key",
+		"1":   "123456789",
+		"2":   "OrderFill",
+		"3":   fillXML,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if activity.Account != "123456789" || activity.Type != ActivityOrderFill {
+		t.Errorf("unexpected activity: %#v", activity)
+	}
+	fill, ok := activity.Message.(*OrderFillMessage)
+	if !ok {
+		t.Fatalf("expected an OrderFillMessage, got %T", activity.Message)
+	}
+	if fill.OrderID() != 3012345678 || fill.Order.Security.Symbol != "SPY_061920P300" || fill.Order.OriginalQuantity != 2 {
+		t.Errorf("unexpected order: %#v", fill.Order)
+	}
+	if fill.Order.OrderPricing.Limit != tdam.Dollars(1.05) {
+		t.Errorf("limit %s", fill.Order.OrderPricing.Limit)
+	}
+	exec := fill.ExecutionInformation
+	if exec.Quantity != 2 || exec.ExecutionPrice != tdam.Dollars(1.06) || exec.LeavesQuantity != 0 {
+		t.Errorf("unexpected execution: %#v", exec)
+	}
+	want := time.Date(2020, 6, 22, 14, 30, 1, 500e6, time.UTC)
+	if !exec.Timestamp.Equal(want) {
+		t.Errorf("execution time %v, want %v", exec.Timestamp, want)
+	}
+	if s := activity.String(); s != "OrderFill order 3012345678: Sell 2 SPY_061920P300 filled 2 @ 1.06" {
+		t.Errorf("unexpected string %q", s)
+	}
+
+	msg, err := DecodeActivity(ActivityOrderCancelReplaceRequest, replaceXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replace := msg.(*OrderCancelReplaceRequestMessage)
+	if replace.OrderID() != 3012345679 || replace.OriginalOrderId != 3012345600 || replace.PendingCancelQuantity != 10 {
+		t.Errorf("unexpected replace: %#v", replace)
+	}
+
+	if msg, err := DecodeActivity(ActivitySubscribed, ""); msg != nil || err != nil {
+		t.Errorf("SUBSCRIBED should decode to nothing, got %v, %v", msg, err)
+	}
+	if _, err := DecodeActivity(ActivityOrderFill, "<OrderFillMessage><Order>"); err == nil {
+		t.Errorf("expected an error for truncated xml")
+	}
+}
@@ -0,0 +1,69 @@
+package streamer
+
+import (
+	"time"
+
+	"github.com/ianmcmahon/tdam"
+)
+
+// ChartEquity is one minute bar from the CHART_EQUITY service, sent as
+// each minute closes
+type ChartEquity struct {
+	Symbol    string
+	Open      tdam.Money
+	High      tdam.Money
+	Low       tdam.Money
+	Close     tdam.Money
+	Volume    int64
+	Sequence  int64
+	ChartTime int64 // epoch millis of the bar's start
+	ChartDay  int   // days since the epoch
+}
+
+func (c ChartEquity) Time() time.Time {
+	return time.Unix(0, c.ChartTime*int64(time.Millisecond))
+}
+
+// Candle is the bar as price history would have it, so streamed bars can
+// carry on from a backfill, eg with ta.FromCandle
+func (c ChartEquity) Candle() tdam.Candle {
+	return tdam.Candle{
+		Open:     c.Open,
+		High:     c.High,
+		Low:      c.Low,
+		Close:    c.Close,
+		Volume:   c.Volume,
+		Datetime: c.ChartTime,
+	}
+}
+
+// ParseChartEquity decodes one packet of CHART_EQUITY content
+func ParseChartEquity(packet map[string]interface{}) ChartEquity {
+	num := func(field string) float64 {
+		f, _ := packet[field].(float64)
+		return f
+	}
+	c := ChartEquity{
+		Open:      tdam.Dollars(num("1")),
+		High:      tdam.Dollars(num("2")),
+		Low:       tdam.Dollars(num("3")),
+		Close:     tdam.Dollars(num("4")),
+		Volume:    int64(num("5")),
+		Sequence:  int64(num("6")),
+		ChartTime: int64(num("7")),
+		ChartDay:  int(num("8")),
+	}
+	c.Symbol, _ = packet["key"].(string)
+	return c
+}
+
+type ChartCallback func(bar ChartEquity)
+
+// SubscribeChartEquity calls cb with every minute bar for symbols
+func (s *Streamer) SubscribeChartEquity(subscriber string, symbols []string, cb ChartCallback) error {
+	return s.Subscribe("CHART_EQUITY", subscriber, symbols, func(symbol string, data Data) {
+		for _, packet := range data.Content {
+			cb(ParseChartEquity(packet))
+		}
+	})
+}
@@ -0,0 +1,32 @@
+package streamer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ianmcmahon/tdam"
+)
+
+func TestParseChartEquity(t *testing.T) {
+	bar := ParseChartEquity(map[string]interface{}{
+		"seq": 11.0,
+		"key": "SPY",
+		"1":   394.01,
+		"2":   394.2,
+		"3":   393.95,
+		"4":   394.06,
+		"5":   152340.0,
+		"6":   571.0,
+		"7":   1615560600000.0,
+		"8":   18698.0,
+	})
+	if bar.Symbol != "SPY" || bar.Open != tdam.Dollars(394.01) || bar.Close != tdam.Dollars(394.06) || bar.Volume != 152340 || bar.Sequence != 571 {
+		t.Errorf("unexpected bar %+v", bar)
+	}
+	if want := time.Date(2021, 3, 12, 14, 50, 0, 0, time.UTC); !bar.Time().Equal(want) {
+		t.Errorf("bar time %v, want %v", bar.Time(), want)
+	}
+	if c := bar.Candle(); c.High != tdam.Dollars(394.2) || c.Low != tdam.Dollars(393.95) || !c.Time().Equal(bar.Time()) {
+		t.Errorf("unexpected candle %v", c)
+	}
+}
@@ -1,6 +1,7 @@
 package streamer
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -52,7 +53,11 @@ func (s *Streamer) nextRequest() int {
 }
 
 func New(client *tdam.Client) (*Streamer, error) {
-	up, err := user.GetUserPrincipals(client)
+	return NewContext(context.Background(), client)
+}
+
+func NewContext(ctx context.Context, client *tdam.Client) (*Streamer, error) {
+	up, err := user.GetUserPrincipalsContext(ctx, client)
 	if err != nil {
 		return nil, err
 	}
@@ -69,12 +74,14 @@ func New(client *tdam.Client) (*Streamer, error) {
 			"OPTION":                   make(map[string]map[string]DataCallback),
 			"LEVELONE_FUTURES":         make(map[string]map[string]DataCallback),
 			"LEVELONE_FUTURES_OPTIONS": make(map[string]map[string]DataCallback),
+			"CHART_EQUITY":             make(map[string]map[string]DataCallback),
 		},
 		subscribers: map[string]map[string][]string{
 			"QUOTE":                    make(map[string][]string),
 			"OPTION":                   make(map[string][]string),
 			"LEVELONE_FUTURES":         make(map[string][]string),
 			"LEVELONE_FUTURES_OPTIONS": make(map[string][]string),
+			"CHART_EQUITY":             make(map[string][]string),
 		},
 	}
 
@@ -85,6 +92,12 @@ func New(client *tdam.Client) (*Streamer, error) {
 }
 
 func (s *Streamer) Run() error {
+	return s.RunContext(context.Background())
+}
+
+// RunContext connects and logs in.  ctx bounds the websocket dial only;
+// once connected, use Stop to shut the streamer down.
+func (s *Streamer) RunContext(ctx context.Context) error {
 	var err error
 
 	if s == nil {
@@ -98,7 +111,7 @@ func (s *Streamer) Run() error {
 	u := url.URL{Scheme: "ws", Host: s.principal.StreamerInfo.StreamerSocketUrl, Path: "/ws"}
 	log.Printf("connecting to %s", u.String())
 
-	s.conn, _, err = websocket.DefaultDialer.Dial(u.String(), nil)
+	s.conn, _, err = websocket.DefaultDialer.DialContext(ctx, u.String(), nil)
 	if err != nil {
 		return err
 	}
@@ -191,7 +204,6 @@ func (s *Streamer) handleIncoming() {
 			s.done <- true
 
 			panic(err) // TODO: panic here so that hopefully docker will restart us
-			return
 		}
 
 		var resp responseWrapper
@@ -37,16 +37,26 @@ func (s *Streamer) Subscribe(service string, subscriber string, symbols []string
 	return nil
 }
 
+// SubscribeAcctActivity calls cb with every ACCT_ACTIVITY message for the
+// principal's accounts.  The messages arrive keyed by the streamer
+// subscription key, not by account id.
 func (s *Streamer) SubscribeAcctActivity(subscriber string, cb DataCallback) error {
+	if s.principal.StreamerSubscriptionKeys == nil || len(*s.principal.StreamerSubscriptionKeys) == 0 {
+		return fmt.Errorf("no streamer subscription key, account activity isn't available")
+	}
+	key := (*s.principal.StreamerSubscriptionKeys)[0]
+
+	s.cbMutex.Lock()
 	if _, ok := s.dataCallbacks["ACCT_ACTIVITY"]; !ok {
 		s.dataCallbacks["ACCT_ACTIVITY"] = make(map[string]map[string]DataCallback)
 	}
+	if _, ok := s.dataCallbacks["ACCT_ACTIVITY"][key]; !ok {
+		s.dataCallbacks["ACCT_ACTIVITY"][key] = make(map[string]DataCallback)
+	}
+	s.dataCallbacks["ACCT_ACTIVITY"][key][subscriber] = cb
+	s.cbMutex.Unlock()
 
 	for _, account := range s.principal.Accounts[0:1] {
-		if _, ok := s.dataCallbacks["ACCT_ACTIVITY"][account.AccountId]; !ok {
-			s.dataCallbacks["ACCT_ACTIVITY"][account.AccountId] = make(map[string]DataCallback)
-		}
-		s.dataCallbacks["ACCT_ACTIVITY"][account.AccountId][subscriber] = cb
 		req := request{
 			Service:   "ACCT_ACTIVITY",
 			Command:   "SUBS",
@@ -54,12 +64,14 @@ func (s *Streamer) SubscribeAcctActivity(subscriber string, cb DataCallback) err
 			Account:   account.AccountId,
 			Source:    s.principal.StreamerInfo.AppId,
 			Parameters: map[string]string{
-				"keys":   (*s.principal.StreamerSubscriptionKeys)[0],
+				"keys":   key,
 				"fields": "0,1,2,3",
 			},
 		}
 		if err := s.sendRequest(req, func(resp response) {
-			fmt.Printf("subAcct: %#v\n", resp)
+			if code, ok := resp.Content["code"].(float64); ok && code != 0 {
+				log.Printf("account activity subscription failed: %v\n", resp.Content["msg"])
+			}
 		}); err != nil {
 			log.Printf("error sending account activity sub request: %v\n", err)
 			continue
@@ -91,6 +103,10 @@ func (s *Streamer) subRequest(service string, symbols []string) request {
 			"fields": "0,1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17,18,19,20,21,22,23,24,25,26,27,28,29,30,31,32,33,34,35,36,37,38,39,40,41,42,43,44,45,46,47,49,51,52",
 		},
 	}
+	if service == "CHART_EQUITY" {
+		// charts only have these
+		req.Parameters["fields"] = "0,1,2,3,4,5,6,7,8"
+	}
 
 	return req
 }
@@ -0,0 +1,86 @@
+package ta
+
+import "math"
+
+type CrossType int
+
+const (
+	NoCross CrossType = iota
+	CrossedAbove
+	CrossedBelow
+)
+
+func (c CrossType) String() string {
+	switch c {
+	case CrossedAbove:
+		return "crossed above"
+	case CrossedBelow:
+		return "crossed below"
+	default:
+		return "no cross"
+	}
+}
+
+// Cross watches two series, like a fast and slow average, or a value and a
+// fixed level, for one crossing the other.  Touching the other series
+// isn't a cross until it comes out the other side.
+//
+//	cross := &ta.Cross{}
+//	if cross.Update(rsi.Update(close), 30) == ta.CrossedBelow { ... }
+type Cross struct {
+	side int // -1 below, 1 above, 0 not known yet
+}
+
+// Update takes the next a and b and reports whether a crossed b
+func (c *Cross) Update(a, b float64) CrossType {
+	if math.IsNaN(a) || math.IsNaN(b) || a == b {
+		return NoCross
+	}
+	side := 1
+	if a < b {
+		side = -1
+	}
+	prev := c.side
+	c.side = side
+	switch {
+	case prev == -1 && side == 1:
+		return CrossedAbove
+	case prev == 1 && side == -1:
+		return CrossedBelow
+	}
+	return NoCross
+}
+
+// CrossAbove is true if a crossed above b at the last value of the series
+func CrossAbove(a, b []float64) bool {
+	return lastCross(a, b) == CrossedAbove
+}
+
+// CrossBelow is true if a crossed below b at the last value of the series
+func CrossBelow(a, b []float64) bool {
+	return lastCross(a, b) == CrossedBelow
+}
+
+func lastCross(a, b []float64) CrossType {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	a, b = a[len(a)-n:], b[len(b)-n:]
+	c := &Cross{}
+	result := NoCross
+	for i := range a {
+		result = c.Update(a[i], b[i])
+	}
+	return result
+}
+
+// Level is a constant series, for crossing a fixed value with CrossAbove
+// and CrossBelow
+func Level(v float64, n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = v
+	}
+	return out
+}
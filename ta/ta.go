@@ -0,0 +1,459 @@
+// Package ta computes technical indicators over price bars.
+//
+// Every indicator is updated one value or bar at a time, so the same code
+// backfills from price history and keeps up with streamed bars:
+//
+//	candles, err := client.GetPriceHistory("SPY", tdam.PeriodYear, 1, tdam.FrequencyDaily, 1, time.Time{}, time.Time{}, false)
+//	rsi := ta.NewRSI(14)
+//	ta.Apply(rsi, ta.Closes(ta.FromCandles(candles)))
+//	...
+//	rsi.Update(latest.Close)
+//
+// Bars from the streamer's CHART_EQUITY service carry on from there:
+//
+//	s.SubscribeChartEquity("rsi", []string{"SPY"}, func(bar streamer.ChartEquity) {
+//		rsi.Update(ta.FromCandle(bar.Candle()).Close)
+//	})
+//
+// Until an indicator has seen enough values it isn't Ready and its value
+// is NaN.
+package ta
+
+import (
+	"math"
+	"time"
+
+	"github.com/ianmcmahon/tdam"
+	"github.com/ianmcmahon/tdam/calendar"
+)
+
+// Bar is one OHLCV bar, from price history or a chart stream
+type Bar struct {
+	Time   time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+}
+
+func FromCandle(c tdam.Candle) Bar {
+	return Bar{
+		Time:   c.Time(),
+		Open:   c.Open.Float64(),
+		High:   c.High.Float64(),
+		Low:    c.Low.Float64(),
+		Close:  c.Close.Float64(),
+		Volume: float64(c.Volume),
+	}
+}
+
+func FromCandles(candles []tdam.Candle) []Bar {
+	bars := make([]Bar, len(candles))
+	for i, c := range candles {
+		bars[i] = FromCandle(c)
+	}
+	return bars
+}
+
+func Closes(bars []Bar) []float64 {
+	out := make([]float64, len(bars))
+	for i, b := range bars {
+		out[i] = b.Close
+	}
+	return out
+}
+
+// Typical is the average of high, low and close
+func (b Bar) Typical() float64 {
+	return (b.High + b.Low + b.Close) / 3
+}
+
+// Indicator is a single valued indicator over a series of values, usually closes
+type Indicator interface {
+	// Update adds the next value and returns the indicator's new value
+	Update(v float64) float64
+	Value() float64
+	Ready() bool
+}
+
+// BarIndicator is a single valued indicator over bars
+type BarIndicator interface {
+	Update(b Bar) float64
+	Value() float64
+	Ready() bool
+}
+
+// Apply feeds values through ind, returning its value after each one
+func Apply(ind Indicator, values []float64) []float64 {
+	out := make([]float64, len(values))
+	for i, v := range values {
+		out[i] = ind.Update(v)
+	}
+	return out
+}
+
+// ApplyBars feeds bars through ind, returning its value after each one
+func ApplyBars(ind BarIndicator, bars []Bar) []float64 {
+	out := make([]float64, len(bars))
+	for i, b := range bars {
+		out[i] = ind.Update(b)
+	}
+	return out
+}
+
+func checkPeriod(n int) {
+	if n < 1 {
+		panic("ta: period must be at least 1")
+	}
+}
+
+// window holds the last n values
+type window struct {
+	values []float64
+	next   int
+	full   bool
+}
+
+func newWindow(n int) *window {
+	return &window{values: make([]float64, n)}
+}
+
+// push adds v, returning the value it pushed out if the window was full
+func (w *window) push(v float64) (old float64, evicted bool) {
+	old, evicted = w.values[w.next], w.full
+	w.values[w.next] = v
+	w.next++
+	if w.next == len(w.values) {
+		w.next = 0
+		w.full = true
+	}
+	return old, evicted
+}
+
+func (w *window) len() int {
+	if w.full {
+		return len(w.values)
+	}
+	return w.next
+}
+
+func (w *window) each(f func(v float64)) {
+	for i := 0; i < w.len(); i++ {
+		f(w.values[i])
+	}
+}
+
+// SMA is the simple moving average of the last N values
+type SMA struct {
+	N     int
+	win   *window
+	sum   float64
+	value float64
+}
+
+func NewSMA(n int) *SMA {
+	checkPeriod(n)
+	return &SMA{N: n, win: newWindow(n), value: math.NaN()}
+}
+
+func (s *SMA) Update(v float64) float64 {
+	if old, ok := s.win.push(v); ok {
+		s.sum -= old
+	}
+	s.sum += v
+	if s.win.full {
+		s.value = s.sum / float64(s.N)
+	}
+	return s.value
+}
+
+func (s *SMA) Value() float64 { return s.value }
+func (s *SMA) Ready() bool    { return s.win.full }
+
+// EMA is the exponential moving average over N values, seeded with the
+// SMA of the first N
+type EMA struct {
+	N     int
+	alpha float64
+	seed  *SMA
+	value float64
+}
+
+func NewEMA(n int) *EMA {
+	checkPeriod(n)
+	return &EMA{N: n, alpha: 2 / float64(n+1), seed: NewSMA(n), value: math.NaN()}
+}
+
+func (e *EMA) Update(v float64) float64 {
+	if !e.seed.Ready() {
+		e.value = e.seed.Update(v)
+		return e.value
+	}
+	e.value += e.alpha * (v - e.value)
+	return e.value
+}
+
+func (e *EMA) Value() float64 { return e.value }
+func (e *EMA) Ready() bool    { return e.seed.Ready() }
+
+// wilder is Wilder's smoothing, an EMA with alpha 1/N seeded with an average
+type wilder struct {
+	n     int
+	count int
+	value float64
+}
+
+func (w *wilder) update(v float64) {
+	w.count++
+	if w.count <= w.n {
+		w.value += (v - w.value) / float64(w.count)
+		return
+	}
+	w.value += (v - w.value) / float64(w.n)
+}
+
+func (w *wilder) ready() bool { return w.count >= w.n }
+
+// RSI is Wilder's relative strength index over N changes, 0 to 100
+type RSI struct {
+	N       int
+	prev    float64
+	started bool
+	gain    wilder
+	loss    wilder
+	value   float64
+}
+
+func NewRSI(n int) *RSI {
+	checkPeriod(n)
+	return &RSI{N: n, gain: wilder{n: n}, loss: wilder{n: n}, value: math.NaN()}
+}
+
+func (r *RSI) Update(v float64) float64 {
+	if !r.started {
+		r.prev, r.started = v, true
+		return r.value
+	}
+	change := v - r.prev
+	r.prev = v
+	r.gain.update(math.Max(change, 0))
+	r.loss.update(math.Max(-change, 0))
+	if !r.gain.ready() {
+		return r.value
+	}
+	switch {
+	case r.loss.value == 0 && r.gain.value == 0:
+		r.value = 50
+	case r.loss.value == 0:
+		r.value = 100
+	default:
+		r.value = 100 - 100/(1+r.gain.value/r.loss.value)
+	}
+	return r.value
+}
+
+func (r *RSI) Value() float64 { return r.value }
+func (r *RSI) Ready() bool    { return r.gain.ready() }
+
+type MACDValue struct {
+	MACD      float64 // fast EMA less slow EMA
+	Signal    float64 // EMA of MACD
+	Histogram float64 // MACD less Signal
+}
+
+// MACD is the moving average convergence divergence, usually 12, 26, 9
+type MACD struct {
+	fast, slow, signal *EMA
+	value              MACDValue
+}
+
+func NewMACD(fast, slow, signal int) *MACD {
+	m := &MACD{fast: NewEMA(fast), slow: NewEMA(slow), signal: NewEMA(signal)}
+	m.value = MACDValue{math.NaN(), math.NaN(), math.NaN()}
+	return m
+}
+
+func (m *MACD) Update(v float64) MACDValue {
+	fast, slow := m.fast.Update(v), m.slow.Update(v)
+	if !m.fast.Ready() || !m.slow.Ready() {
+		return m.value
+	}
+	m.value.MACD = fast - slow
+	m.value.Signal = m.signal.Update(m.value.MACD)
+	m.value.Histogram = m.value.MACD - m.value.Signal
+	return m.value
+}
+
+func (m *MACD) Value() MACDValue { return m.value }
+func (m *MACD) Ready() bool      { return m.signal.Ready() }
+
+type Bands struct {
+	Upper, Middle, Lower float64
+}
+
+// Width is the distance between the bands relative to the middle
+func (b Bands) Width() float64 {
+	return (b.Upper - b.Lower) / b.Middle
+}
+
+// PercentB is where v sits in the bands, 0 at the lower and 1 at the upper
+func (b Bands) PercentB(v float64) float64 {
+	return (v - b.Lower) / (b.Upper - b.Lower)
+}
+
+// Bollinger bands are K standard deviations either side of the N value SMA,
+// usually 20 and 2
+type Bollinger struct {
+	N     int
+	K     float64
+	sma   *SMA
+	value Bands
+}
+
+func NewBollinger(n int, k float64) *Bollinger {
+	checkPeriod(n)
+	return &Bollinger{N: n, K: k, sma: NewSMA(n), value: Bands{math.NaN(), math.NaN(), math.NaN()}}
+}
+
+func (b *Bollinger) Update(v float64) Bands {
+	mean := b.sma.Update(v)
+	if !b.sma.Ready() {
+		return b.value
+	}
+	var variance float64
+	b.sma.win.each(func(x float64) {
+		variance += (x - mean) * (x - mean)
+	})
+	dev := b.K * math.Sqrt(variance/float64(b.N))
+	b.value = Bands{Upper: mean + dev, Middle: mean, Lower: mean - dev}
+	return b.value
+}
+
+func (b *Bollinger) Value() Bands { return b.value }
+func (b *Bollinger) Ready() bool  { return b.sma.Ready() }
+
+// ATR is Wilder's average true range over N bars
+type ATR struct {
+	N         int
+	prevClose float64
+	started   bool
+	tr        wilder
+	value     float64
+}
+
+func NewATR(n int) *ATR {
+	checkPeriod(n)
+	return &ATR{N: n, tr: wilder{n: n}, value: math.NaN()}
+}
+
+// TrueRange is the bar's range, extended to the previous close if it gapped
+func TrueRange(b Bar, prevClose float64) float64 {
+	return math.Max(b.High, prevClose) - math.Min(b.Low, prevClose)
+}
+
+func (a *ATR) Update(b Bar) float64 {
+	tr := b.High - b.Low
+	if a.started {
+		tr = TrueRange(b, a.prevClose)
+	}
+	a.prevClose, a.started = b.Close, true
+	a.tr.update(tr)
+	if a.tr.ready() {
+		a.value = a.tr.value
+	}
+	return a.value
+}
+
+func (a *ATR) Value() float64 { return a.value }
+func (a *ATR) Ready() bool    { return a.tr.ready() }
+
+// VWAP is the volume weighted average of bars' typical prices.  It starts
+// over with each trading day, going by the date of bars' times in New
+// York, or on Reset.
+type VWAP struct {
+	day    time.Time
+	pv     float64
+	volume float64
+	value  float64
+}
+
+func NewVWAP() *VWAP {
+	return &VWAP{value: math.NaN()}
+}
+
+func (v *VWAP) Reset() {
+	*v = VWAP{value: math.NaN()}
+}
+
+func (v *VWAP) Update(b Bar) float64 {
+	if !b.Time.IsZero() {
+		day := calendar.DateOf(b.Time)
+		if !day.Equal(v.day) {
+			v.Reset()
+			v.day = day
+		}
+	}
+	v.pv += b.Typical() * b.Volume
+	v.volume += b.Volume
+	if v.volume > 0 {
+		v.value = v.pv / v.volume
+	}
+	return v.value
+}
+
+func (v *VWAP) Value() float64 { return v.value }
+func (v *VWAP) Ready() bool    { return v.volume > 0 }
+
+type StochasticValue struct {
+	K, D float64
+}
+
+// Stochastic is the stochastic oscillator: %K is where the close sits in
+// the last N bars' range, 0 to 100, smoothed over Smooth bars, and %D is
+// the D bar SMA of %K.  14, 3, 3 is the usual slow stochastic, a Smooth of
+// 1 gives the fast one.
+type Stochastic struct {
+	N           int
+	highs, lows *window
+	smooth, d   *SMA
+	value       StochasticValue
+}
+
+func NewStochastic(n, smooth, d int) *Stochastic {
+	checkPeriod(n)
+	return &Stochastic{
+		N:      n,
+		highs:  newWindow(n),
+		lows:   newWindow(n),
+		smooth: NewSMA(smooth),
+		d:      NewSMA(d),
+		value:  StochasticValue{math.NaN(), math.NaN()},
+	}
+}
+
+func (s *Stochastic) Update(b Bar) StochasticValue {
+	s.highs.push(b.High)
+	s.lows.push(b.Low)
+	if !s.highs.full {
+		return s.value
+	}
+	high, low := math.Inf(-1), math.Inf(1)
+	s.highs.each(func(v float64) { high = math.Max(high, v) })
+	s.lows.each(func(v float64) { low = math.Min(low, v) })
+
+	raw := 50.0
+	if high > low {
+		raw = (b.Close - low) / (high - low) * 100
+	}
+	k := s.smooth.Update(raw)
+	if !s.smooth.Ready() {
+		return s.value
+	}
+	s.value.K = k
+	s.value.D = s.d.Update(k)
+	return s.value
+}
+
+func (s *Stochastic) Value() StochasticValue { return s.value }
+func (s *Stochastic) Ready() bool            { return s.d.Ready() }
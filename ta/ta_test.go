@@ -0,0 +1,228 @@
+package ta
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ianmcmahon/tdam"
+	"github.com/ianmcmahon/tdam/streamer"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestSMAAndEMA(t *testing.T) {
+	sma := NewSMA(3)
+	got := Apply(sma, []float64{1, 2, 3, 4, 5})
+	if !math.IsNaN(got[1]) || got[2] != 2 || got[3] != 3 || got[4] != 4 {
+		t.Errorf("sma %v", got)
+	}
+
+	ema := NewEMA(3)
+	got = Apply(ema, []float64{1, 2, 3, 6, 6})
+	// seeded at 2, then alpha 0.5
+	if !math.IsNaN(got[1]) || got[2] != 2 || got[3] != 4 || got[4] != 5 || !ema.Ready() {
+		t.Errorf("ema %v", got)
+	}
+}
+
+func TestRSI(t *testing.T) {
+	// Wilder's example as worked by StockCharts
+	closes := []float64{44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42,
+		45.84, 46.08, 45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03, 46.41}
+	rsi := NewRSI(14)
+	got := Apply(rsi, closes)
+	if !math.IsNaN(got[13]) {
+		t.Errorf("rsi shouldn't be ready after 13 changes: %v", got[13])
+	}
+	for i, want := range map[int]float64{14: 70.46, 15: 66.25, 16: 66.48, 17: 69.35} {
+		if math.Abs(got[i]-want) > 0.01 {
+			t.Errorf("rsi[%d] = %.4f, want %.2f", i, got[i], want)
+		}
+	}
+
+	flat := NewRSI(2)
+	Apply(flat, []float64{1, 1, 1})
+	if flat.Value() != 50 {
+		t.Errorf("flat rsi %v", flat.Value())
+	}
+}
+
+func TestMACD(t *testing.T) {
+	closes := make([]float64, 60)
+	for i := range closes {
+		closes[i] = 100 + 10*math.Sin(float64(i)/5)
+	}
+	macd := NewMACD(12, 26, 9)
+	fast, slow := NewEMA(12), NewEMA(26)
+	var signal []float64
+	for i, c := range closes {
+		v := macd.Update(c)
+		f, s := fast.Update(c), slow.Update(c)
+		if i < 25 {
+			if !math.IsNaN(v.MACD) {
+				t.Fatalf("macd ready too soon at %d", i)
+			}
+			continue
+		}
+		if !near(v.MACD, f-s) {
+			t.Errorf("macd[%d] = %v, want %v", i, v.MACD, f-s)
+		}
+		signal = append(signal, f-s)
+		if i == 25+8 {
+			want := 0.0
+			for _, x := range signal {
+				want += x / 9
+			}
+			if !near(v.Signal, want) || !near(v.Histogram, v.MACD-want) || !macd.Ready() {
+				t.Errorf("signal %v, want %v", v, want)
+			}
+		}
+	}
+}
+
+func TestBollinger(t *testing.T) {
+	b := NewBollinger(4, 2)
+	var bands Bands
+	for _, v := range []float64{2, 4, 4, 4, 5, 5, 7, 9} {
+		bands = b.Update(v)
+	}
+	// the last four are 5, 5, 7, 9: mean 6.5, deviation sqrt(2.75)
+	dev := 2 * math.Sqrt(2.75)
+	if !near(bands.Middle, 6.5) || !near(bands.Upper, 6.5+dev) || !near(bands.Lower, 6.5-dev) {
+		t.Errorf("bands %+v", bands)
+	}
+	if !near(bands.PercentB(6.5), 0.5) {
+		t.Errorf("percent b %v", bands.PercentB(6.5))
+	}
+}
+
+func TestATR(t *testing.T) {
+	atr := NewATR(2)
+	got := ApplyBars(atr, []Bar{
+		{High: 10, Low: 8, Close: 9},   // 2
+		{High: 11, Low: 9, Close: 10},  // 2
+		{High: 15, Low: 12, Close: 14}, // gapped up, 15 - 10 = 5
+	})
+	if !math.IsNaN(got[0]) || got[1] != 2 || got[2] != 3.5 {
+		t.Errorf("atr %v", got)
+	}
+}
+
+func TestVWAP(t *testing.T) {
+	day := time.Date(2021, 3, 12, 9, 30, 0, 0, time.UTC)
+	v := NewVWAP()
+	v.Update(Bar{Time: day, High: 11, Low: 9, Close: 10, Volume: 100})
+	got := v.Update(Bar{Time: day.Add(time.Minute), High: 21, Low: 19, Close: 20, Volume: 300})
+	if got != 17.5 {
+		t.Errorf("vwap %v", got)
+	}
+	// next day starts over
+	got = v.Update(Bar{Time: day.AddDate(0, 0, 1), High: 6, Low: 4, Close: 5, Volume: 10})
+	if got != 5 {
+		t.Errorf("vwap after new day %v", got)
+	}
+
+	// days are New York's: 7:30 and 8:30 in the evening there are one day,
+	// though they're either side of midnight in UTC
+	evening := time.Date(2021, 3, 14, 23, 30, 0, 0, time.UTC)
+	v.Update(Bar{Time: evening, High: 11, Low: 9, Close: 10, Volume: 100})
+	got = v.Update(Bar{Time: evening.Add(time.Hour), High: 21, Low: 19, Close: 20, Volume: 300})
+	if got != 17.5 {
+		t.Errorf("vwap across midnight UTC %v", got)
+	}
+}
+
+func TestStochastic(t *testing.T) {
+	s := NewStochastic(3, 1, 2)
+	bars := []Bar{
+		{High: 10, Low: 8, Close: 9},
+		{High: 12, Low: 9, Close: 11},
+		{High: 13, Low: 10, Close: 12}, // range 8-13, K 80
+		{High: 14, Low: 11, Close: 11}, // range 9-14, K 40
+	}
+	var v StochasticValue
+	for _, b := range bars {
+		v = s.Update(b)
+	}
+	if !near(v.K, 40) || !near(v.D, 60) || !s.Ready() {
+		t.Errorf("stochastic %+v", v)
+	}
+}
+
+func TestCross(t *testing.T) {
+	c := &Cross{}
+	var got []CrossType
+	for _, v := range []float64{math.NaN(), 35, 30, 25, 28, 30, 31} {
+		got = append(got, c.Update(v, 30))
+	}
+	want := []CrossType{NoCross, NoCross, NoCross, CrossedBelow, NoCross, NoCross, CrossedAbove}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("update %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	fast := []float64{1, 2, 3, 4}
+	slow := []float64{2.5, 2.5, 2.5}
+	if CrossAbove(fast, slow) || !CrossAbove(fast[:3], slow[:2]) {
+		t.Errorf("cross above should be only at the crossing value")
+	}
+	if !CrossBelow([]float64{75, 72, 69}, Level(70, 3)) {
+		t.Errorf("expected a cross below 70")
+	}
+}
+
+// backfilling from price history then updating from the chart stream
+// should come out the same as having had every bar up front
+func TestBackfillThenStream(t *testing.T) {
+	start := time.Date(2021, 3, 12, 14, 30, 0, 0, time.UTC)
+	price := func(i int) float64 { return 390 + 3*math.Sin(float64(i)/4) }
+	var candles []tdam.Candle
+	var packets []map[string]interface{}
+	for i := 0; i < 40; i++ {
+		at := start.Add(time.Duration(i)*time.Minute).UnixNano() / int64(time.Millisecond)
+		p := price(i)
+		if i < 30 {
+			candles = append(candles, tdam.Candle{
+				Open: tdam.Dollars(p - 0.1), High: tdam.Dollars(p + 0.5), Low: tdam.Dollars(p - 0.5),
+				Close: tdam.Dollars(p), Volume: 1000, Datetime: at,
+			})
+			continue
+		}
+		packets = append(packets, map[string]interface{}{
+			"key": "SPY", "1": p - 0.1, "2": p + 0.5, "3": p - 0.5, "4": p,
+			"5": 1000.0, "6": float64(i), "7": float64(at), "8": 18698.0,
+		})
+	}
+
+	rsi, atr, vwap := NewRSI(14), NewATR(14), NewVWAP()
+	ApplyBars(vwap, FromCandles(candles))
+	ApplyBars(atr, FromCandles(candles))
+	Apply(rsi, Closes(FromCandles(candles)))
+	if !rsi.Ready() || !atr.Ready() {
+		t.Fatal("expected the backfill to warm the indicators up")
+	}
+
+	all := FromCandles(candles)
+	for _, packet := range packets {
+		bar := FromCandle(streamer.ParseChartEquity(packet).Candle())
+		all = append(all, bar)
+		rsi.Update(bar.Close)
+		atr.Update(bar)
+		vwap.Update(bar)
+	}
+
+	wantRSI := Apply(NewRSI(14), Closes(all))
+	wantATR := ApplyBars(NewATR(14), all)
+	wantVWAP := ApplyBars(NewVWAP(), all)
+	if !near(rsi.Value(), wantRSI[len(all)-1]) || !near(atr.Value(), wantATR[len(all)-1]) || !near(vwap.Value(), wantVWAP[len(all)-1]) {
+		t.Errorf("streamed rsi %v atr %v vwap %v, want %v %v %v", rsi.Value(), atr.Value(), vwap.Value(),
+			wantRSI[len(all)-1], wantATR[len(all)-1], wantVWAP[len(all)-1])
+	}
+	if !all[len(all)-1].Time.Equal(start.Add(39 * time.Minute)) {
+		t.Errorf("last bar at %v", all[len(all)-1].Time)
+	}
+}
@@ -0,0 +1,730 @@
+// Package tdamtest provides an in-memory fake of the TD Ameritrade REST API
+// for testing code built on tdam.Client without credentials or network.
+package tdamtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ianmcmahon/tdam"
+	"github.com/ianmcmahon/tdam/user"
+)
+
+const (
+	ConsumerKey  = "TESTKEY"
+	AccessToken  = "test-access-token"
+	RefreshToken = "test-refresh-token"
+	AccountID    = "123456789"
+)
+
+// State is the fake's data.  Modify it through Server.Update.
+type State struct {
+	Accounts     []tdam.SecuritiesAccount
+	Orders       map[string][]tdam.Order       // by account id
+	SavedOrders  map[string][]tdam.Order       // by account id
+	Transactions map[string][]tdam.Transaction // by account id
+	Watchlists   []tdam.Watchlist
+	Chains       map[string][]byte // raw chain responses by symbol
+	Quotes       map[string]tdam.Quote
+	PriceHistory map[string][]tdam.Candle // by symbol, oldest first
+	Hours        []*tdam.MarketHours
+	Instruments  map[string]*tdam.MarketInstrument // by symbol
+	Principal    *user.UserPrincipal
+
+	nextOrderID int
+}
+
+type Server struct {
+	*httptest.Server
+
+	ConsumerKey  string
+	AccessToken  string
+	RefreshToken string
+
+	mu       sync.Mutex
+	state    *State
+	requests []string
+}
+
+// NewServer starts a fake with one margin account and the SPY option
+// chain from options/testdata.  Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		ConsumerKey:  ConsumerKey,
+		AccessToken:  AccessToken,
+		RefreshToken: RefreshToken,
+		state:        DefaultState(),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+func DefaultState() *State {
+	st := &State{
+		Accounts: []tdam.SecuritiesAccount{{
+			Type:      tdam.MARGIN,
+			AccountId: AccountID,
+			CurrentBalances: tdam.Balances{
+				CashBalance:             10000 * tdam.Dollar,
+				CashAvailableForTrading: 10000 * tdam.Dollar,
+				LiquidationValue:        10000 * tdam.Dollar,
+			},
+		}},
+		Orders:       map[string][]tdam.Order{},
+		SavedOrders:  map[string][]tdam.Order{},
+		Transactions: map[string][]tdam.Transaction{},
+		Chains:       map[string][]byte{},
+		Quotes:       map[string]tdam.Quote{},
+		PriceHistory: map[string][]tdam.Candle{},
+		Instruments:  map[string]*tdam.MarketInstrument{},
+		Principal: &user.UserPrincipal{
+			UserId:           "testuser",
+			PrimaryAccountId: AccountID,
+			StreamerInfo: user.StreamerInfo{
+				StreamerSocketUrl: "localhost",
+				Token:             "streamer-token",
+				TokenTimestamp:    user.TokenTimestamp(time.Now()),
+				AppId:             "test",
+				Acl:               "AKBRDRESTFTFCN",
+			},
+			StreamerSubscriptionKeys: &user.SubscriptionKeys{"subscription-key"},
+			Accounts: []user.UPAccount{{
+				AccountId: AccountID,
+				Company:   "AMER",
+				Segment:   "AMER",
+				Authorizations: user.Authorizations{
+					StockTrading:       true,
+					MarginTrading:      true,
+					OptionTradingLevel: "SPREAD",
+				},
+			}},
+		},
+		nextOrderID: 1000,
+	}
+	if chain, err := Fixture("spy_options_response.json"); err == nil {
+		st.Chains["SPY"] = chain
+	}
+	return st
+}
+
+// Fixture reads a file from the options package's testdata
+func Fixture(name string) ([]byte, error) {
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		return nil, fmt.Errorf("can't locate tdamtest source")
+	}
+	return ioutil.ReadFile(filepath.Join(filepath.Dir(file), "..", "options", "testdata", name))
+}
+
+// Client returns a tdam.Client pointed at the fake, already holding a valid token.
+func (s *Server) Client() *tdam.Client {
+	c := tdam.NewClient(s.ConsumerKey)
+	c.APIBaseURL = s.URL
+	c.AuthBaseURL = s.URL
+	c.TokenURL = s.URL + "/v1/oauth2/token"
+	c.HTTPClient = s.Server.Client()
+	c.RateLimiter = nil
+	c.TokenStore = tdam.NewMemoryTokenStore()
+	c.TokenStore.SaveToken(&tdam.TokenResponse{
+		AccessToken:   s.AccessToken,
+		AccessExpiry:  time.Now().Add(30 * time.Minute),
+		RefreshToken:  s.RefreshToken,
+		RefreshExpiry: time.Now().Add(90 * 24 * time.Hour),
+	})
+	return c
+}
+
+// Update runs f with exclusive access to the fake's state
+func (s *Server) Update(f func(st *State)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(s.state)
+}
+
+// Requests lists "METHOD /path" for every request received so far
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+func (s *Server) authorized(req *http.Request) bool {
+	return req.Header.Get("Authorization") == "Bearer "+s.AccessToken
+}
+
+func (s *Server) handle(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req.Method+" "+req.URL.Path)
+
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "v1" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	parts = parts[1:]
+
+	switch {
+	case parts[0] == "oauth2" && len(parts) == 2 && parts[1] == "token":
+		s.handleToken(w, req)
+		return
+	case parts[0] == "marketdata" && len(parts) == 2 && parts[1] == "chains":
+		s.handleChains(w, req)
+		return
+	case parts[0] == "marketdata" && len(parts) == 2 && parts[1] == "quotes":
+		s.handleQuotes(w, req)
+		return
+	case parts[0] == "instruments":
+		s.handleInstruments(w, req, parts[1:])
+		return
+	case parts[0] == "marketdata" && len(parts) == 2 && parts[1] == "hours":
+		s.handleHours(w, req)
+		return
+	case parts[0] == "marketdata" && len(parts) == 3 && parts[2] == "pricehistory":
+		s.handlePriceHistory(w, req, parts[1])
+		return
+	}
+
+	if !s.authorized(req) {
+		writeError(w, http.StatusUnauthorized, "The access token being passed has expired or is invalid.")
+		return
+	}
+
+	switch {
+	case parts[0] == "userprincipals":
+		writeJSON(w, 200, s.state.Principal)
+	case parts[0] == "orders":
+		s.listOrders(w, req, req.URL.Query().Get("accountId"))
+	case parts[0] == "accounts" && len(parts) == 1:
+		s.handleAccounts(w, "")
+	case parts[0] == "accounts" && parts[1] == "watchlists":
+		writeJSON(w, 200, s.state.Watchlists)
+	case parts[0] == "accounts" && len(parts) == 2:
+		s.handleAccounts(w, parts[1])
+	case parts[0] == "accounts" && parts[2] == "transactions":
+		s.handleTransactions(w, req, parts[1])
+	case parts[0] == "accounts" && parts[2] == "orders":
+		s.handleOrders(w, req, parts[1], parts[3:])
+	case parts[0] == "accounts" && parts[2] == "savedorders":
+		s.handleSavedOrders(w, req, parts[1], parts[3:])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	req.ParseForm()
+	if req.Form.Get("client_id") != s.ConsumerKey {
+		writeError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	resp := map[string]interface{}{
+		"access_token": s.AccessToken,
+		"token_type":   "Bearer",
+		"expires_in":   1800,
+	}
+	switch req.Form.Get("grant_type") {
+	case "authorization_code":
+		if req.Form.Get("code") == "" {
+			writeError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+	case "refresh_token":
+		if req.Form.Get("refresh_token") != s.RefreshToken {
+			writeError(w, http.StatusUnauthorized, "invalid_grant")
+			return
+		}
+		if req.Form.Get("access_type") != "offline" {
+			writeJSON(w, 200, resp)
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+	resp["refresh_token"] = s.RefreshToken
+	resp["refresh_token_expires_in"] = 7776000
+	writeJSON(w, 200, resp)
+}
+
+func (s *Server) handleChains(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	if query.Get("apikey") != s.ConsumerKey && !s.authorized(req) {
+		writeError(w, http.StatusUnauthorized, "invalid apikey")
+		return
+	}
+	chain, ok := s.state.Chains[query.Get("symbol")]
+	if !ok {
+		writeJSON(w, 200, map[string]string{"symbol": query.Get("symbol"), "status": "FAILED"})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(chain)
+}
+
+// without a token quotes need the consumer key, and come back delayed
+func (s *Server) handleQuotes(w http.ResponseWriter, req *http.Request) {
+	authorized := s.authorized(req)
+	if !authorized && req.URL.Query().Get("apikey") != s.ConsumerKey {
+		writeError(w, http.StatusUnauthorized, "invalid apikey")
+		return
+	}
+	out := map[string]interface{}{}
+	for _, symbol := range strings.Split(req.URL.Query().Get("symbol"), ",") {
+		q, ok := s.state.Quotes[symbol]
+		if !ok {
+			continue
+		}
+		b, err := json.Marshal(q)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		var m map[string]interface{}
+		json.Unmarshal(b, &m)
+		m["delayed"] = !authorized
+		out[symbol] = m
+	}
+	writeJSON(w, 200, out)
+}
+
+// candles between startDate and endDate inclusive, like TD, so chunked
+// requests overlap
+func (s *Server) handlePriceHistory(w http.ResponseWriter, req *http.Request, symbol string) {
+	query := req.URL.Query()
+	if query.Get("apikey") != s.ConsumerKey && !s.authorized(req) {
+		writeError(w, http.StatusUnauthorized, "invalid apikey")
+		return
+	}
+	from, to := int64(0), int64(math.MaxInt64)
+	if v := query.Get("startDate"); v != "" {
+		from, _ = strconv.ParseInt(v, 10, 64)
+	}
+	if v := query.Get("endDate"); v != "" {
+		to, _ = strconv.ParseInt(v, 10, 64)
+	}
+	candles := []tdam.Candle{}
+	for _, c := range s.state.PriceHistory[symbol] {
+		if c.Datetime >= from && c.Datetime <= to {
+			candles = append(candles, c)
+		}
+	}
+	writeJSON(w, 200, map[string]interface{}{
+		"symbol":  symbol,
+		"empty":   len(candles) == 0,
+		"candles": candles,
+	})
+}
+
+// the requested markets' hours on date, keyed by market in lower case and
+// then product, as TD does
+func (s *Server) handleHours(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	if query.Get("apikey") != s.ConsumerKey && !s.authorized(req) {
+		writeError(w, http.StatusUnauthorized, "invalid apikey")
+		return
+	}
+	out := map[string]map[string]*tdam.MarketHours{}
+	for _, market := range strings.Split(query.Get("markets"), ",") {
+		for _, h := range s.state.Hours {
+			if string(h.MarketType) != market || h.Date != query.Get("date") {
+				continue
+			}
+			key := strings.ToLower(market)
+			if out[key] == nil {
+				out[key] = map[string]*tdam.MarketHours{}
+			}
+			out[key][h.Product] = h
+		}
+	}
+	writeJSON(w, 200, out)
+}
+
+// searches by projection, or looks up a cusip when given one in the path.
+// Fundamentals are only included for the fundamental projection.
+func (s *Server) handleInstruments(w http.ResponseWriter, req *http.Request, rest []string) {
+	query := req.URL.Query()
+	if query.Get("apikey") != s.ConsumerKey && !s.authorized(req) {
+		writeError(w, http.StatusUnauthorized, "invalid apikey")
+		return
+	}
+	strip := func(i *tdam.MarketInstrument) *tdam.MarketInstrument {
+		c := *i
+		c.Fundamental = nil
+		return &c
+	}
+
+	if len(rest) == 1 {
+		found := []*tdam.MarketInstrument{}
+		for _, i := range s.state.Instruments {
+			if i.Cusip == rest[0] {
+				found = append(found, strip(i))
+			}
+		}
+		writeJSON(w, 200, found)
+		return
+	}
+
+	q := query.Get("symbol")
+	var match func(i *tdam.MarketInstrument) bool
+	switch tdam.Projection(query.Get("projection")) {
+	case tdam.ProjectionSymbolSearch, tdam.ProjectionFundamental:
+		match = func(i *tdam.MarketInstrument) bool { return i.Symbol == q }
+	case tdam.ProjectionDescSearch:
+		match = func(i *tdam.MarketInstrument) bool {
+			return strings.Contains(strings.ToLower(i.Description), strings.ToLower(q))
+		}
+	case tdam.ProjectionSymbolRegex:
+		// the whole symbol has to match
+		re, err := regexp.Compile("^(?:" + q + ")$")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		match = func(i *tdam.MarketInstrument) bool { return re.MatchString(i.Symbol) }
+	case tdam.ProjectionDescRegex:
+		re, err := regexp.Compile(q)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		match = func(i *tdam.MarketInstrument) bool { return re.MatchString(i.Description) }
+	default:
+		writeError(w, http.StatusBadRequest, "invalid projection")
+		return
+	}
+
+	out := map[string]*tdam.MarketInstrument{}
+	for symbol, i := range s.state.Instruments {
+		if !match(i) {
+			continue
+		}
+		if query.Get("projection") == string(tdam.ProjectionFundamental) {
+			out[symbol] = i
+		} else {
+			out[symbol] = strip(i)
+		}
+	}
+	writeJSON(w, 200, out)
+}
+
+func (s *Server) account(id string) (tdam.SecuritiesAccount, bool) {
+	for _, a := range s.state.Accounts {
+		if a.AccountId == id {
+			return a, true
+		}
+	}
+	return tdam.SecuritiesAccount{}, false
+}
+
+func (s *Server) handleAccounts(w http.ResponseWriter, id string) {
+	if id == "" {
+		out := []tdam.Account{}
+		for _, a := range s.state.Accounts {
+			out = append(out, tdam.Account{SecuritiesAccount: a})
+		}
+		writeJSON(w, 200, out)
+		return
+	}
+	a, ok := s.account(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "account not found")
+		return
+	}
+	writeJSON(w, 200, tdam.Account{SecuritiesAccount: a})
+}
+
+func (s *Server) handleTransactions(w http.ResponseWriter, req *http.Request, accountID string) {
+	if _, ok := s.account(accountID); !ok {
+		writeError(w, http.StatusNotFound, "account not found")
+		return
+	}
+	query := req.URL.Query()
+	out := []tdam.Transaction{}
+	for _, t := range s.state.Transactions[accountID] {
+		if typ := query.Get("type"); typ != "" && typ != "ALL" && t.Type != typ {
+			continue
+		}
+		if sym := query.Get("symbol"); sym != "" {
+			if t.TransactionItem == nil || t.TransactionItem.Instrument == nil {
+				continue
+			}
+			i := t.TransactionItem.Instrument
+			if string(i.Symbol) != sym && string(i.UnderlyingSymbol) != sym {
+				continue
+			}
+		}
+		out = append(out, t)
+	}
+	writeJSON(w, 200, out)
+}
+
+func (s *Server) listOrders(w http.ResponseWriter, req *http.Request, accountID string) {
+	status := req.URL.Query().Get("status")
+	max, _ := strconv.Atoi(req.URL.Query().Get("maxResults"))
+
+	out := []tdam.Order{}
+	for id, orders := range s.state.Orders {
+		if accountID != "" && id != accountID {
+			continue
+		}
+		for _, o := range orders {
+			if status != "" && string(o.Status) != status {
+				continue
+			}
+			out = append(out, o)
+		}
+	}
+	if max > 0 && len(out) > max {
+		out = out[:max]
+	}
+	writeJSON(w, 200, out)
+}
+
+func (s *Server) findOrder(accountID string, orderID int) (int, bool) {
+	for i, o := range s.state.Orders[accountID] {
+		if o.OrderId == orderID {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (s *Server) handleOrders(w http.ResponseWriter, req *http.Request, accountID string, rest []string) {
+	if _, ok := s.account(accountID); !ok {
+		writeError(w, http.StatusNotFound, "account not found")
+		return
+	}
+
+	if len(rest) == 0 {
+		switch req.Method {
+		case "GET":
+			s.listOrders(w, req, accountID)
+		case "POST":
+			s.placeOrder(w, req, accountID, -1)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	orderID, err := strconv.Atoi(rest[0])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid order id")
+		return
+	}
+	idx, ok := s.findOrder(accountID, orderID)
+	if !ok {
+		writeError(w, http.StatusNotFound, "order not found")
+		return
+	}
+	order := &s.state.Orders[accountID][idx]
+
+	switch req.Method {
+	case "GET":
+		writeJSON(w, 200, order)
+	case "DELETE":
+		if !order.Cancelable {
+			writeError(w, http.StatusBadRequest, "order is not cancelable")
+			return
+		}
+		order.Status = tdam.StatusCanceled
+		order.Cancelable = false
+		order.Editable = false
+		w.WriteHeader(200)
+	case "PUT":
+		if !order.Editable {
+			writeError(w, http.StatusBadRequest, "order is not editable")
+			return
+		}
+		s.placeOrder(w, req, accountID, idx)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// places a new order, replacing the order at index replace if >= 0
+func (s *Server) placeOrder(w http.ResponseWriter, req *http.Request, accountID string, replace int) {
+	order, ok := s.decodeOrder(w, req)
+	if !ok {
+		return
+	}
+	s.acceptOrder(&order, accountID, tdam.StatusQueued)
+
+	if replace >= 0 {
+		old := &s.state.Orders[accountID][replace]
+		old.Status = tdam.StatusReplaced
+		old.Cancelable = false
+		old.Editable = false
+		old.ReplacingOrderCollection = []tdam.Order{order}
+	}
+	s.state.Orders[accountID] = append(s.state.Orders[accountID], order)
+
+	w.Header().Set("Location", fmt.Sprintf("%s/v1/accounts/%s/orders/%d", s.URL, accountID, order.OrderId))
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) handleSavedOrders(w http.ResponseWriter, req *http.Request, accountID string, rest []string) {
+	if _, ok := s.account(accountID); !ok {
+		writeError(w, http.StatusNotFound, "account not found")
+		return
+	}
+	saved := s.state.SavedOrders[accountID]
+
+	if len(rest) == 0 {
+		switch req.Method {
+		case "GET":
+			writeJSON(w, 200, append([]tdam.Order{}, saved...))
+		case "POST":
+			order, ok := s.decodeOrder(w, req)
+			if !ok {
+				return
+			}
+			s.state.nextOrderID++
+			order.SavedOrderId = s.state.nextOrderID
+			now := tdam.TDTime(time.Now().UTC().Truncate(time.Second))
+			order.SavedTime = &now
+			s.state.SavedOrders[accountID] = append(saved, order)
+
+			w.Header().Set("Location", fmt.Sprintf("%s/v1/accounts/%s/savedorders/%d", s.URL, accountID, order.SavedOrderId))
+			w.WriteHeader(http.StatusCreated)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	id, err := strconv.Atoi(rest[0])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid saved order id")
+		return
+	}
+	idx := -1
+	for i, o := range saved {
+		if o.SavedOrderId == id {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		writeError(w, http.StatusNotFound, "saved order not found")
+		return
+	}
+
+	switch req.Method {
+	case "GET":
+		writeJSON(w, 200, saved[idx])
+	case "PUT":
+		order, ok := s.decodeOrder(w, req)
+		if !ok {
+			return
+		}
+		order.SavedOrderId = id
+		now := tdam.TDTime(time.Now().UTC().Truncate(time.Second))
+		order.SavedTime = &now
+		saved[idx] = order
+		w.WriteHeader(200)
+	case "DELETE":
+		s.state.SavedOrders[accountID] = append(saved[:idx:idx], saved[idx+1:]...)
+		w.WriteHeader(200)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) decodeOrder(w http.ResponseWriter, req *http.Request) (tdam.Order, bool) {
+	var order tdam.Order
+	if err := json.NewDecoder(req.Body).Decode(&order); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid order: %v", err))
+		return order, false
+	}
+	if err := order.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return order, false
+	}
+	return order, true
+}
+
+// assigns ids down through child orders, those waiting on a trigger aren't working yet
+func (s *Server) acceptOrder(order *tdam.Order, accountID string, status tdam.OrderStatus) {
+	s.state.nextOrderID++
+	order.OrderId = s.state.nextOrderID
+	order.AccountId, _ = strconv.Atoi(accountID)
+	order.Status = status
+	order.Cancelable = true
+	order.Editable = true
+	order.RemainingQuantity = order.Quantity
+	now := tdam.TDTime(time.Now().UTC().Truncate(time.Second))
+	order.EnteredTime = &now
+
+	if order.OrderStrategyType == tdam.StrategyTrigger {
+		status = tdam.StatusAwaitingParentOrder
+	}
+	for i := range order.ChildOrderStrategies {
+		s.acceptOrder(&order.ChildOrderStrategies[i], accountID, status)
+	}
+}
+
+// FillOrder fills every leg of an account's order at price, as a single
+// execution.  It's false if there's no such open order.
+func (s *Server) FillOrder(accountID string, orderID int, price tdam.Money) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx, ok := s.findOrder(accountID, orderID)
+	if !ok {
+		return false
+	}
+	order := &s.state.Orders[accountID][idx]
+	if order.Status.Final() {
+		return false
+	}
+
+	now := tdam.TDTime(time.Now().UTC().Truncate(time.Second))
+	exec := tdam.Execution{
+		ActivityType:  tdam.ActivityExecution,
+		ActivityId:    int64(len(order.OrderActivityCollection) + 1),
+		ExecutionType: tdam.ExecutionFill,
+		Quantity:      order.RemainingQuantity,
+	}
+	for _, leg := range order.OrderLegCollection {
+		exec.ExecutionLegs = append(exec.ExecutionLegs, tdam.ExecutionLeg{
+			LegId:    leg.LegId,
+			Quantity: leg.Quantity,
+			Price:    price,
+			Time:     &now,
+		})
+	}
+	order.OrderActivityCollection = append(order.OrderActivityCollection, exec)
+	order.FilledQuantity = order.Quantity
+	order.RemainingQuantity = 0
+	order.Status = tdam.StatusFilled
+	order.Cancelable = false
+	order.Editable = false
+	order.CloseTime = &now
+	return true
+}
@@ -0,0 +1,87 @@
+package tdamtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ianmcmahon/tdam"
+	"github.com/ianmcmahon/tdam/options"
+	"github.com/ianmcmahon/tdam/user"
+)
+
+func TestFakeServer(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	c := srv.Client()
+
+	srv.Update(func(st *State) {
+		st.Watchlists = []tdam.Watchlist{{Name: "spreads", AccountId: AccountID}}
+		st.Transactions[AccountID] = []tdam.Transaction{{
+			Type:        "TRADE",
+			Description: "BUY TRADE",
+			TransactionItem: &tdam.TransactionItem{
+				Instruction: "BUY",
+				Amount:      100,
+				Price:       tdam.Dollars(320.5),
+				Instrument:  &tdam.Instrument{AssetType: tdam.EQUITY, Symbol: "SPY"},
+			},
+		}}
+	})
+
+	accounts, err := c.GetAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 1 || accounts[0].AccountId != AccountID {
+		t.Fatalf("unexpected accounts: %#v", accounts)
+	}
+
+	start := time.Now().AddDate(0, -1, 0)
+	txs, err := accounts[0].TradeHistory("SPY", &start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 1 || txs[0].TransactionItem.Price != tdam.Dollars(320.5) {
+		t.Errorf("unexpected transactions: %#v", txs)
+	}
+
+	watchlists, err := c.GetWatchlists()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(watchlists) != 1 || watchlists[0].Name != "spreads" {
+		t.Errorf("unexpected watchlists: %#v", watchlists)
+	}
+
+	up, err := user.GetUserPrincipals(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if up.PrimaryAccountId != AccountID || (*up.StreamerSubscriptionKeys)[0] != "subscription-key" {
+		t.Errorf("unexpected principal: %#v", up)
+	}
+	up.Authorize(accounts)
+	if accounts[0].OptionTradingLevel != tdam.OptionLevelSpread {
+		t.Errorf("expected SPREAD option level, got %q", accounts[0].OptionTradingLevel)
+	}
+
+	chain, err := (&options.Client{Client: c}).GetChain("SPY", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chain.Symbol != "SPY" || len(chain.ExpirationDates()) == 0 {
+		t.Errorf("unexpected chain: %s with %d expirations", chain.Symbol, len(chain.ExpirationDates()))
+	}
+}
+
+func TestFakeServerRejectsBadToken(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	srv.AccessToken = "rotated"
+
+	_, err := c.GetAccounts()
+	if !tdam.IsUnauthorized(err) {
+		t.Errorf("expected unauthorized, got %v", err)
+	}
+}
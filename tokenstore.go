@@ -0,0 +1,197 @@
+package tdam
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenStore persists a client's access and refresh tokens between runs.
+// LoadToken returns a nil token (and nil error) if nothing is stored yet.
+type TokenStore interface {
+	LoadToken() (*TokenResponse, error)
+	SaveToken(token *TokenResponse) error
+}
+
+// storedToken is the on-disk form of a TokenResponse, including expiries
+type storedToken struct {
+	AccessToken   string    `json:"access_token"`
+	AccessExpiry  time.Time `json:"access_expiry"`
+	RefreshToken  string    `json:"refresh_token"`
+	RefreshExpiry time.Time `json:"refresh_expiry"`
+	TokenType     string    `json:"token_type,omitempty"`
+}
+
+func toStored(t *TokenResponse) storedToken {
+	return storedToken{
+		AccessToken:   t.AccessToken,
+		AccessExpiry:  t.AccessExpiry,
+		RefreshToken:  t.RefreshToken,
+		RefreshExpiry: t.RefreshExpiry,
+		TokenType:     t.TokenType,
+	}
+}
+
+func (s storedToken) token() *TokenResponse {
+	return &TokenResponse{
+		AccessToken:   s.AccessToken,
+		AccessExpiry:  s.AccessExpiry,
+		RefreshToken:  s.RefreshToken,
+		RefreshExpiry: s.RefreshExpiry,
+		TokenType:     s.TokenType,
+	}
+}
+
+func copyToken(t *TokenResponse) *TokenResponse {
+	if t == nil {
+		return nil
+	}
+	cp := *t
+	return &cp
+}
+
+// MemoryTokenStore keeps the token in process memory only.
+type MemoryTokenStore struct {
+	mu    sync.Mutex
+	token *TokenResponse
+}
+
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+func (s *MemoryTokenStore) LoadToken() (*TokenResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyToken(s.token), nil
+}
+
+func (s *MemoryTokenStore) SaveToken(token *TokenResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = copyToken(token)
+	return nil
+}
+
+// FileTokenStore keeps the token as json in a file readable only by the owner.
+// Writes go to a temp file that is renamed into place.
+type FileTokenStore struct {
+	Path string
+}
+
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{Path: path}
+}
+
+// DefaultTokenPath is ~/.tdam/tdam_refresh
+func DefaultTokenPath() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(configDir, "tdam_refresh"), nil
+}
+
+func (s *FileTokenStore) LoadToken() (*TokenResponse, error) {
+	b, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var st storedToken
+	if err := json.Unmarshal(b, &st); err != nil {
+		// older versions wrote the bare refresh token
+		raw := strings.TrimSpace(string(b))
+		if raw == "" {
+			return nil, nil
+		}
+		return &TokenResponse{RefreshToken: raw}, nil
+	}
+	return st.token(), nil
+}
+
+func (s *FileTokenStore) SaveToken(token *TokenResponse) error {
+	b, err := json.MarshalIndent(toStored(token), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.Path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(dir, ".tdam-token-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // no-op once renamed
+
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.Path)
+}
+
+// EnvTokenStore reads the refresh token (and optionally an access token)
+// from environment variables.  The environment is never written; saved
+// tokens are kept in memory for the life of the process.
+type EnvTokenStore struct {
+	RefreshVar string // defaults to REFRESH_TOKEN
+	AccessVar  string // optional
+
+	MemoryTokenStore
+}
+
+func NewEnvTokenStore() *EnvTokenStore {
+	return &EnvTokenStore{RefreshVar: "REFRESH_TOKEN"}
+}
+
+func (s *EnvTokenStore) LoadToken() (*TokenResponse, error) {
+	if t, _ := s.MemoryTokenStore.LoadToken(); t != nil {
+		return t, nil
+	}
+
+	refreshVar := s.RefreshVar
+	if refreshVar == "" {
+		refreshVar = "REFRESH_TOKEN"
+	}
+	refresh := os.Getenv(refreshVar)
+	if refresh == "" {
+		return nil, nil
+	}
+	token := &TokenResponse{RefreshToken: refresh}
+	if s.AccessVar != "" {
+		token.AccessToken = os.Getenv(s.AccessVar)
+	}
+	return token, nil
+}
+
+// the store used by a Client with no TokenStore set:
+// REFRESH_TOKEN from the environment if present, otherwise ~/.tdam/tdam_refresh
+func defaultTokenStore() (TokenStore, error) {
+	if os.Getenv("REFRESH_TOKEN") != "" {
+		return NewEnvTokenStore(), nil
+	}
+	p, err := DefaultTokenPath()
+	if err != nil {
+		return nil, err
+	}
+	return NewFileTokenStore(p), nil
+}
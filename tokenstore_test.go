@@ -0,0 +1,89 @@
+package tdam
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileTokenStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tdam-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewFileTokenStore(filepath.Join(dir, "token.json"))
+	if tok, err := store.LoadToken(); err != nil || tok != nil {
+		t.Fatalf("expected empty store, got %v, %v", tok, err)
+	}
+
+	expiry := time.Now().Add(90 * 24 * time.Hour).Round(time.Second)
+	if err := store.SaveToken(&TokenResponse{
+		AccessToken:   "access",
+		AccessExpiry:  expiry,
+		RefreshToken:  "refresh",
+		RefreshExpiry: expiry,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(store.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("token file mode is %v", fi.Mode().Perm())
+	}
+
+	tok, err := store.LoadToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "access" || tok.RefreshToken != "refresh" || !tok.RefreshExpiry.Equal(expiry) {
+		t.Errorf("unexpected token: %#v", tok)
+	}
+}
+
+func TestFileTokenStoreLegacy(t *testing.T) {
+	f, err := ioutil.TempFile("", "tdam_refresh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("legacy-refresh-token")
+	f.Close()
+
+	tok, err := NewFileTokenStore(f.Name()).LoadToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.RefreshToken != "legacy-refresh-token" {
+		t.Errorf("unexpected refresh token %q", tok.RefreshToken)
+	}
+}
+
+func TestSetToken(t *testing.T) {
+	c := NewClient("KEY")
+	c.TokenStore = NewMemoryTokenStore()
+	if err := c.SetToken(nil); err == nil {
+		t.Error("expected a nil token to be rejected")
+	}
+
+	if err := c.SetToken(&TokenResponse{AccessToken: "first", RefreshToken: "refresh"}); err != nil {
+		t.Fatal(err)
+	}
+	// refresh grants come back without a refresh token
+	if err := c.SetToken(&TokenResponse{AccessToken: "second"}); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := c.TokenStore.LoadToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "second" || tok.RefreshToken != "refresh" {
+		t.Errorf("unexpected token: %#v", tok)
+	}
+}
@@ -0,0 +1,479 @@
+package tdam
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ianmcmahon/tdam/calendar"
+)
+
+// Final is true once the order can't change any more
+func (s OrderStatus) Final() bool {
+	switch s {
+	case StatusFilled, StatusCanceled, StatusRejected, StatusReplaced, StatusExpired:
+		return true
+	}
+	return false
+}
+
+// how far along an order is, QUEUED -> WORKING -> FILLED etc.  Updates
+// that would move an order backwards are stale and get dropped.
+func (s OrderStatus) stage() int {
+	switch s {
+	case "":
+		return 0
+	case StatusWorking, StatusPendingCancel, StatusPendingReplace:
+		return 2
+	}
+	if s.Final() {
+		return 3
+	}
+	return 1
+}
+
+// Fill is one leg of an execution
+type Fill struct {
+	OrderId     int
+	ActivityId  int64
+	LegId       int
+	Symbol      string
+	Instruction Instruction
+	Quantity    float64
+	Price       Money
+	Time        time.Time
+}
+
+func (f Fill) String() string {
+	return fmt.Sprintf("%s %g %s @ %s", f.Instruction, f.Quantity, f.Symbol, f.Price)
+}
+
+// fills lists the executions in the order's activity collection
+func (o *Order) fills() []Fill {
+	legs := map[int]OrderLegCollection{}
+	for _, leg := range o.OrderLegCollection {
+		legs[leg.LegId] = leg
+	}
+
+	var fills []Fill
+	for _, activity := range o.OrderActivityCollection {
+		if activity.ActivityType != ActivityExecution {
+			continue
+		}
+		for _, exec := range activity.ExecutionLegs {
+			f := Fill{
+				OrderId:    o.OrderId,
+				ActivityId: activity.ActivityId,
+				LegId:      exec.LegId,
+				Quantity:   exec.Quantity,
+				Price:      exec.Price,
+			}
+			if leg, ok := legs[exec.LegId]; ok {
+				f.Instruction = leg.Instruction
+				if leg.Instrument != nil {
+					f.Symbol = leg.Instrument.Info().Symbol
+				}
+			}
+			if exec.Time != nil {
+				f.Time = time.Time(*exec.Time)
+			}
+			fills = append(fills, f)
+		}
+	}
+	return fills
+}
+
+// AveragePrice is the quantity weighted price of fills, zero if there are none
+func AveragePrice(fills []Fill) Money {
+	var total Money
+	var quantity float64
+	for _, f := range fills {
+		total += f.Price.Mul(f.Quantity)
+		quantity += f.Quantity
+	}
+	if quantity == 0 {
+		return 0
+	}
+	return Dollars(total.Float64() / quantity)
+}
+
+// OrderEvent is sent whenever a tracked order changes status or fills
+type OrderEvent struct {
+	Order    Order
+	Previous OrderStatus // empty the first time the order is seen
+	Fills    []Fill      // new since the last event
+}
+
+func (e OrderEvent) Status() OrderStatus {
+	return e.Order.Status
+}
+
+// OrderNotFilledError is returned by WaitForFill when the order ends
+// without filling.  It may still have been partially filled.
+type OrderNotFilledError struct {
+	Order Order
+}
+
+func (e *OrderNotFilledError) Error() string {
+	return fmt.Sprintf("order %d %s", e.Order.OrderId, strings.ToLower(string(e.Order.Status)))
+}
+
+type OrderTrackerOptions struct {
+	// how often to poll GetOrders.  Default 30 seconds, or 5 seconds if
+	// there's no account activity stream calling Notify
+	PollInterval time.Duration
+
+	// orders entered before this aren't tracked unless asked for by id.
+	// Default the start of today in New York
+	Since time.Time
+
+	// called from the tracker goroutine for every event
+	OnEvent func(OrderEvent)
+
+	// called from the tracker goroutine whenever fetching orders fails
+	OnError func(error)
+
+	// if set, events are also sent here, dropped if the channel is full
+	Events chan<- OrderEvent
+
+	// set when something, eg streamer.Streamer.TrackOrders, calls Notify
+	// on account activity, so polling is only a backstop
+	Streaming bool
+}
+
+func (o *OrderTrackerOptions) setDefaults() {
+	if o.PollInterval <= 0 {
+		o.PollInterval = 5 * time.Second
+		if o.Streaming {
+			o.PollInterval = 30 * time.Second
+		}
+	}
+	if o.Since.IsZero() {
+		o.Since = calendar.DateOf(time.Now())
+	}
+}
+
+// WaitForFill gives up on an order after this many failed fetches in a row,
+// or straight away if it isn't found
+const trackerMaxFailures = 3
+
+type trackedOrder struct {
+	order    Order
+	fills    []Fill
+	seen     map[[2]int64]bool // activity id, leg id
+	err      error             // from the last fetch, nil once one succeeds
+	failures int
+}
+
+// OrderTracker follows an account's orders through their lifecycle,
+// polling GetOrders and refreshing single orders whenever Notify reports
+// account activity.  Start one with Account.TrackOrders:
+//
+//	tracker := account.TrackOrders(ctx, tdam.OrderTrackerOptions{})
+//	defer tracker.Stop()
+//	id, err := account.PlaceOrder(order)
+//	filled, err := tracker.WaitForFill(ctx, id)
+type OrderTracker struct {
+	account *Account
+	opts    OrderTrackerOptions
+
+	mu          sync.Mutex
+	orders      map[int]*trackedOrder
+	changed     chan struct{} // closed and replaced on every change
+	subscribers map[chan OrderEvent]bool
+	stopped     bool
+	err         error // from the last poll
+	failures    int
+
+	notify chan int
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// TrackOrders starts an OrderTracker, which runs until ctx is done or
+// Stop is called
+func (a *Account) TrackOrders(ctx context.Context, opts OrderTrackerOptions) *OrderTracker {
+	opts.setDefaults()
+	ctx, cancel := context.WithCancel(ctx)
+	t := &OrderTracker{
+		account:     a,
+		opts:        opts,
+		orders:      map[int]*trackedOrder{},
+		changed:     make(chan struct{}),
+		subscribers: map[chan OrderEvent]bool{},
+		notify:      make(chan int, 64),
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+	go t.run(ctx)
+	return t
+}
+
+func (t *OrderTracker) Stop() {
+	t.cancel()
+	<-t.done
+}
+
+func (t *OrderTracker) run(ctx context.Context) {
+	defer close(t.done)
+	defer t.closeSubscribers()
+
+	t.poll(ctx)
+	ticker := time.NewTicker(t.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.poll(ctx)
+		case id := <-t.notify:
+			if id == 0 {
+				t.poll(ctx)
+			} else {
+				t.refresh(ctx, id)
+			}
+		}
+	}
+}
+
+// Notify reports activity on orderID, which is fetched right away.
+// An orderID of 0 polls all orders.  It never blocks, if the tracker
+// is busy the next poll catches up.
+func (t *OrderTracker) Notify(orderID int) {
+	select {
+	case t.notify <- orderID:
+	default:
+	}
+}
+
+// Track follows orderID even if it was entered before Since
+func (t *OrderTracker) Track(orderID int) {
+	t.mu.Lock()
+	if _, ok := t.orders[orderID]; !ok {
+		t.orders[orderID] = &trackedOrder{seen: map[[2]int64]bool{}}
+	}
+	t.mu.Unlock()
+	t.Notify(orderID)
+}
+
+func (t *OrderTracker) poll(ctx context.Context) {
+	orders, err := t.account.GetOrdersContext(ctx, OrderFilter{FromEnteredTime: t.opts.Since})
+	if err != nil {
+		t.fail(0, err)
+		return
+	}
+	t.mu.Lock()
+	t.err, t.failures = nil, 0
+	t.mu.Unlock()
+
+	seen := map[int]bool{}
+	for i := range orders {
+		t.update(&orders[i], seen)
+	}
+
+	// anything still open that didn't come back, eg tracked from an earlier day
+	var missing []int
+	t.mu.Lock()
+	for id, o := range t.orders {
+		if !seen[id] && !o.order.Status.Final() && !IsNotFound(o.err) {
+			missing = append(missing, id)
+		}
+	}
+	t.mu.Unlock()
+	for _, id := range missing {
+		t.refresh(ctx, id)
+	}
+}
+
+func (t *OrderTracker) refresh(ctx context.Context, orderID int) {
+	order, err := t.account.GetOrderContext(ctx, orderID)
+	if err != nil {
+		t.fail(orderID, err)
+		return
+	}
+	t.update(order, map[int]bool{})
+}
+
+// fail records err against a tracked order, or against polling if orderID
+// is 0, and wakes WaitForFill so it can give up
+func (t *OrderTracker) fail(orderID int, err error) {
+	t.mu.Lock()
+	if orderID == 0 {
+		t.err = err
+		t.failures++
+	} else if o, ok := t.orders[orderID]; ok {
+		o.err = err
+		o.failures++
+	}
+	close(t.changed)
+	t.changed = make(chan struct{})
+	t.mu.Unlock()
+
+	if t.opts.OnError != nil {
+		t.opts.OnError(err)
+	}
+}
+
+// Err is the error from the last poll, nil if it succeeded
+func (t *OrderTracker) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+// update records order and the orders nested in it
+func (t *OrderTracker) update(order *Order, seen map[int]bool) {
+	seen[order.OrderId] = true
+	for i := range order.ChildOrderStrategies {
+		t.update(&order.ChildOrderStrategies[i], seen)
+	}
+	for i := range order.ReplacingOrderCollection {
+		t.update(&order.ReplacingOrderCollection[i], seen)
+	}
+
+	t.mu.Lock()
+	o, ok := t.orders[order.OrderId]
+	if !ok {
+		o = &trackedOrder{seen: map[[2]int64]bool{}}
+		t.orders[order.OrderId] = o
+	}
+	o.err, o.failures = nil, 0
+	previous := o.order.Status
+	if order.Status.stage() < previous.stage() {
+		t.mu.Unlock()
+		return
+	}
+
+	var fills []Fill
+	for _, f := range order.fills() {
+		key := [2]int64{f.ActivityId, int64(f.LegId)}
+		if !o.seen[key] {
+			o.seen[key] = true
+			fills = append(fills, f)
+		}
+	}
+	o.order = *order
+	o.fills = append(o.fills, fills...)
+
+	if previous == order.Status && len(fills) == 0 {
+		t.mu.Unlock()
+		return
+	}
+	close(t.changed)
+	t.changed = make(chan struct{})
+	e := OrderEvent{Order: *order, Previous: previous, Fills: fills}
+	for ch := range t.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+	t.mu.Unlock()
+
+	if t.opts.OnEvent != nil {
+		t.opts.OnEvent(e)
+	}
+	if t.opts.Events != nil {
+		select {
+		case t.opts.Events <- e:
+		default:
+		}
+	}
+}
+
+// Order is the latest state of orderID, false if it hasn't been seen yet
+func (t *OrderTracker) Order(orderID int) (Order, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	o, ok := t.orders[orderID]
+	if !ok || o.order.Status == "" {
+		return Order{}, false
+	}
+	return o.order, true
+}
+
+// Fills lists orderID's fills so far
+func (t *OrderTracker) Fills(orderID int) []Fill {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if o, ok := t.orders[orderID]; ok {
+		return append([]Fill(nil), o.fills...)
+	}
+	return nil
+}
+
+// Subscribe returns a channel of every event from now on.  Events are
+// dropped if the channel's buffer fills, so use WaitForFill to be sure of
+// an outcome.  The channel is closed by unsubscribe or when the tracker stops.
+func (t *OrderTracker) Subscribe(buffer int) (events <-chan OrderEvent, unsubscribe func()) {
+	ch := make(chan OrderEvent, buffer)
+	t.mu.Lock()
+	if t.stopped {
+		close(ch)
+	} else {
+		t.subscribers[ch] = true
+	}
+	t.mu.Unlock()
+
+	return ch, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.subscribers[ch] {
+			delete(t.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (t *OrderTracker) closeSubscribers() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+	for ch := range t.subscribers {
+		delete(t.subscribers, ch)
+		close(ch)
+	}
+}
+
+// WaitForFill blocks until orderID fills, returning the filled order.
+// If the order is replaced it waits on the replacement instead.  Orders
+// that are canceled, rejected or expire return an *OrderNotFilledError.
+// It gives up with the API's error if the order isn't found, or if
+// fetching it or polling keeps failing.
+func (t *OrderTracker) WaitForFill(ctx context.Context, orderID int) (Order, error) {
+	t.Track(orderID)
+	for {
+		t.mu.Lock()
+		o := t.orders[orderID]
+		order, err, failures := o.order, o.err, o.failures
+		pollErr, pollFailures := t.err, t.failures
+		changed := t.changed
+		t.mu.Unlock()
+
+		switch {
+		case err != nil && (IsNotFound(err) || failures >= trackerMaxFailures):
+			return order, fmt.Errorf("tracking order %d: %w", orderID, err)
+		case pollFailures >= trackerMaxFailures:
+			return order, fmt.Errorf("tracking order %d: %w", orderID, pollErr)
+		case order.Status == StatusFilled:
+			return order, nil
+		case order.Status == StatusReplaced && len(order.ReplacingOrderCollection) > 0:
+			orderID = order.ReplacingOrderCollection[0].OrderId
+			t.Track(orderID)
+			continue
+		case order.Status.Final():
+			return order, &OrderNotFilledError{Order: order}
+		}
+
+		select {
+		case <-ctx.Done():
+			return order, ctx.Err()
+		case <-t.done:
+			return order, fmt.Errorf("order tracker stopped")
+		case <-changed:
+		}
+	}
+}
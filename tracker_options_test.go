@@ -0,0 +1,25 @@
+package tdam
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ianmcmahon/tdam/calendar"
+)
+
+func TestOrderTrackerOptionsDefaults(t *testing.T) {
+	var o OrderTrackerOptions
+	o.setDefaults()
+	if o.PollInterval != 5*time.Second {
+		t.Errorf("poll interval %v", o.PollInterval)
+	}
+	if o.Since.Location() != calendar.NewYork || !o.Since.Equal(calendar.DateOf(time.Now())) {
+		t.Errorf("since %v, want midnight in New York", o.Since)
+	}
+
+	o = OrderTrackerOptions{Streaming: true, Since: time.Unix(0, 0)}
+	o.setDefaults()
+	if o.PollInterval != 30*time.Second || !o.Since.Equal(time.Unix(0, 0)) {
+		t.Errorf("unexpected options %+v", o)
+	}
+}
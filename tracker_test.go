@@ -0,0 +1,139 @@
+package tdam_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ianmcmahon/tdam"
+	"github.com/ianmcmahon/tdam/tdamtest"
+)
+
+func TestOrderTracker(t *testing.T) {
+	srv := tdamtest.NewServer()
+	defer srv.Close()
+
+	accounts, err := srv.Client().GetAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := accounts[0]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events := make(chan tdam.OrderEvent, 10)
+	tracker := a.TrackOrders(ctx, tdam.OrderTrackerOptions{PollInterval: time.Hour, Events: events})
+	defer tracker.Stop()
+
+	order, err := tdam.Equity("SPY").Buy(10).Limit(400).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := a.PlaceOrder(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker.Track(id)
+	if e := <-events; e.Order.OrderId != id || e.Previous != "" || e.Status() != tdam.StatusQueued {
+		t.Errorf("unexpected first event: %#v", e)
+	}
+
+	// replaced at a better price, then filled
+	newID, err := a.ReplaceOrder(id, order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.FillOrder(tdamtest.AccountID, newID, tdam.Dollars(399.95))
+	tracker.Notify(id)
+
+	filled, err := tracker.WaitForFill(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filled.OrderId != newID || filled.Status != tdam.StatusFilled {
+		t.Errorf("expected the replacement to fill, got %#v", filled)
+	}
+	fills := tracker.Fills(newID)
+	if len(fills) != 1 || fills[0].Symbol != "SPY" || fills[0].Quantity != 10 || fills[0].Price != tdam.Dollars(399.95) {
+		t.Errorf("unexpected fills: %v", fills)
+	}
+	if avg := tdam.AveragePrice(fills); avg != tdam.Dollars(399.95) {
+		t.Errorf("average price %s", avg)
+	}
+	if o, ok := tracker.Order(id); !ok || o.Status != tdam.StatusReplaced {
+		t.Errorf("original should be replaced, got %#v", o)
+	}
+
+	// a canceled order doesn't fill
+	id, err = a.PlaceOrder(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.CancelOrder(id); err != nil {
+		t.Fatal(err)
+	}
+	_, err = tracker.WaitForFill(ctx, id)
+	var notFilled *tdam.OrderNotFilledError
+	if !errors.As(err, &notFilled) || notFilled.Order.Status != tdam.StatusCanceled {
+		t.Errorf("expected not filled error, got %v", err)
+	}
+}
+
+func TestOrderTrackerErrors(t *testing.T) {
+	srv := tdamtest.NewServer()
+	defer srv.Close()
+
+	accounts, err := srv.Client().GetAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := accounts[0]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	errs := make(chan error, 100)
+	tracker := a.TrackOrders(ctx, tdam.OrderTrackerOptions{
+		PollInterval: 10 * time.Millisecond,
+		OnError: func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		},
+	})
+	defer tracker.Stop()
+
+	// an order that doesn't exist fails straight away
+	if _, err := tracker.WaitForFill(ctx, 424242); !tdam.IsNotFound(err) {
+		t.Errorf("expected not found, got %v", err)
+	}
+
+	// and so does an order that goes missing, eg when the account can't be seen
+	order, err := tdam.Equity("SPY").Buy(10).Limit(400).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := a.PlaceOrder(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Update(func(st *tdamtest.State) {
+		st.Accounts = nil
+	})
+	_, err = tracker.WaitForFill(ctx, id)
+	if err == nil || ctx.Err() != nil {
+		t.Fatalf("expected WaitForFill to give up before the deadline, got %v", err)
+	}
+	select {
+	case <-errs:
+	case <-ctx.Done():
+		t.Fatal("expected OnError to be called")
+	}
+	for tracker.Err() == nil && ctx.Err() == nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !tdam.IsNotFound(tracker.Err()) {
+		t.Errorf("expected the last poll error to be kept, got %v", tracker.Err())
+	}
+}
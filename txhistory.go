@@ -1,24 +1,20 @@
 package tdam
 
 import (
-	"crypto/tls"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"net/http"
+	"net/url"
 	"time"
 )
 
 func (a *Account) TradeHistoryCallback(symbol Symbol, start *time.Time, cb func(symbol Symbol, data []byte)) error {
-	token, err := a.TDAMToken()
-	if err != nil {
-		return err
-	}
-
-	transport := &http.Transport{TLSClientConfig: &tls.Config{}}
-	client := &http.Client{Transport: transport}
+	return a.TradeHistoryCallbackContext(context.Background(), symbol, start, cb)
+}
 
-	endpoint := fmt.Sprintf("https://api.tdameritrade.com/v1/accounts/%s/transactions", a.AccountId)
+func (a *Account) TradeHistoryCallbackContext(ctx context.Context, symbol Symbol, start *time.Time, cb func(symbol Symbol, data []byte)) error {
+	endpoint := fmt.Sprintf("/v1/accounts/%s/transactions", a.AccountId)
 
 	current := time.Now()
 	if current.Sub(*start) < 1*time.Hour {
@@ -26,12 +22,6 @@ func (a *Account) TradeHistoryCallback(symbol Symbol, start *time.Time, cb func(
 		return nil
 	}
 	for {
-		req, err := http.NewRequest("GET", endpoint, nil)
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-
 		prevMonth := current.AddDate(0, -6, 0)
 		startTime := start
 		if startTime == nil || startTime.Before(prevMonth) {
@@ -39,19 +29,23 @@ func (a *Account) TradeHistoryCallback(symbol Symbol, start *time.Time, cb func(
 		}
 		//fmt.Printf("%s: %s - %s\n", symbol, startTime, current)
 
-		query := req.URL.Query()
+		query := url.Values{}
 		query.Add("type", "TRADE")
 		query.Add("symbol", string(symbol))
 		query.Add("startDate", startTime.Format("2006-01-02"))
 		query.Add("endDate", current.Format("2006-01-02"))
-		req.URL.RawQuery = query.Encode()
-		resp, err := client.Do(req)
+		resp, err := a.DoContext(ctx, Request{
+			Endpoint:      endpoint,
+			Query:         query,
+			Authenticated: true,
+		})
 		if err != nil {
 			return err
 		}
 
-		if resp.StatusCode != 200 {
-			return fmt.Errorf("status %d: %s\n", resp.StatusCode, resp.Status)
+		if err := CheckResponse(resp); err != nil {
+			resp.Body.Close()
+			return err
 		}
 
 		body, _ := ioutil.ReadAll(resp.Body)
@@ -71,15 +65,16 @@ func (a *Account) TradeHistoryCallback(symbol Symbol, start *time.Time, cb func(
 		if start != nil && current.Before(*start) {
 			return nil
 		}
-		time.Sleep(100 * time.Millisecond)
 	}
+}
 
-	return nil
+func (a *Account) TradeHistory(symbol Symbol, start *time.Time) ([]Transaction, error) {
+	return a.TradeHistoryContext(context.Background(), symbol, start)
 }
 
-func (a *Account) TradeHistory(symbol Symbol, start *time.Time) (out []Transaction, err error) {
+func (a *Account) TradeHistoryContext(ctx context.Context, symbol Symbol, start *time.Time) (out []Transaction, err error) {
 	out = []Transaction{}
-	a.TradeHistoryCallback(symbol, start, func(symbol Symbol, data []byte) {
+	cbErr := a.TradeHistoryCallbackContext(ctx, symbol, start, func(symbol Symbol, data []byte) {
 		var transactions []Transaction
 		//fmt.Printf("%s: %s\n", symbol, data)
 		if e := json.Unmarshal(data, &transactions); e != nil {
@@ -88,6 +83,9 @@ func (a *Account) TradeHistory(symbol Symbol, start *time.Time) (out []Transacti
 			out = append(out, transactions...)
 		}
 	})
+	if err == nil {
+		err = cbErr
+	}
 
 	return
 }
@@ -2,38 +2,80 @@ package tdam
 
 import (
 	"fmt"
-	"regexp"
-	"strconv"
 	"time"
 )
 
 type TDTime time.Time
 
-func (e TDTime) UnmarshalJSON(b []byte) (err error) {
-	s := string(b)
+const tdTimeFormat = "2006-01-02T15:04:05-0700"
 
-	// Get rid of the quotes "" around the value.
-	// A second option would be to include them
-	// in the date format string instead, like so below:
-	//   time.Parse(`"`+time.RFC3339Nano+`"`, s)
-	s = s[1 : len(s)-1]
+func (e *TDTime) UnmarshalJSON(b []byte) (err error) {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+	s, err = unquoteTime(s)
+	if err != nil {
+		return err
+	}
 
-	t, err := time.Parse("2006-01-02T15:04:05-0700", s)
+	t, err := time.Parse(tdTimeFormat, s)
 	if err == nil {
-		e = TDTime(t)
+		*e = TDTime(t)
 	}
 	return
 }
 
-type Expiration TDTime
+// strips the quotes from a JSON string, times are never anything else
+func unquoteTime(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("expected a quoted time, got %s", s)
+	}
+	return s[1 : len(s)-1], nil
+}
 
-func (e Expiration) UnmarshalJSON(b []byte) (err error) {
-	t := TDTime(e)
-	err = t.UnmarshalJSON(b)
+func (e TDTime) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + time.Time(e).Format(tdTimeFormat) + `"`), nil
+}
+
+// TDDate is a date without a time, like an order's cancelTime
+type TDDate TDTime
+
+const tdDateFormat = "2006-01-02"
+
+func (d *TDDate) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+	s, err := unquoteTime(s)
+	if err != nil {
+		return err
+	}
+
+	t, err := time.Parse(tdDateFormat, s)
+	if err != nil {
+		// sometimes we get a full timestamp
+		t, err = time.Parse(tdTimeFormat, s)
+	}
 	if err == nil {
-		e = Expiration(t)
+		*d = TDDate(t)
 	}
-	return
+	return err
+}
+
+func (d TDDate) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + time.Time(d).Format(tdDateFormat) + `"`), nil
+}
+
+type Expiration TDTime
+
+func (e *Expiration) UnmarshalJSON(b []byte) error {
+	return (*TDTime)(e).UnmarshalJSON(b)
+}
+
+func (e Expiration) MarshalJSON() ([]byte, error) {
+	return TDTime(e).MarshalJSON()
 }
 
 func (e Expiration) String() string {
@@ -46,10 +88,10 @@ type Transaction struct {
 	SubAccount                    string           `json:"subAccount"`
 	SettlementDate                string           `json:"settlementDate"`
 	OrderID                       string           `json:"orderId"`
-	SMA                           float64          `json:"sma"`
-	RequirementReallocationAmount float64          `json:"requirementReallocationAmount"`
-	DayTradeBPE                   float64          `json:"dayTradeBuyingPowerEffect"`
-	NetAmount                     float64          `json:"netAmount"`
+	SMA                           Money            `json:"sma"`
+	RequirementReallocationAmount Money            `json:"requirementReallocationAmount"`
+	DayTradeBPE                   Money            `json:"dayTradeBuyingPowerEffect"`
+	NetAmount                     Money            `json:"netAmount"`
 	TransactionDate               string           `json:"transactionDate"`
 	OrderDate                     string           `json:"orderDate"`
 	TransactionSubType            string           `json:"transactionSubType"`
@@ -57,16 +99,16 @@ type Transaction struct {
 	CashBalanceEffectFlag         bool             `json:"cashBalanceEffectFlag"`
 	Description                   string           `json:"description"`
 	ACHStatus                     string           `json:"achStatus"`
-	AccruedInterest               float64          `json:"accruedInterest"`
-	Fees                          interface{}      `json:"fees"`
+	AccruedInterest               Money            `json:"accruedInterest"`
+	Fees                          map[string]Money `json:"fees"`
 	TransactionItem               *TransactionItem `json:"transactionItem"`
 }
 
 type TransactionItem struct {
 	AccountID            int         `json:"accountId"`
 	Amount               float64     `json:"amount"`
-	Price                float64     `json:"price"`
-	Cost                 float64     `json:"cost"`
+	Price                Money       `json:"price"`
+	Cost                 Money       `json:"cost"`
 	ParentOrderKey       int         `json:"parentOrderKey"`
 	ParentChildIndicator string      `json:"parentChildIndicator"`
 	Instruction          string      `json:"instruction"`
@@ -89,7 +131,7 @@ type Instrument struct {
 
 func (t *Transaction) String() string {
 	i := t.TransactionItem
-	return fmt.Sprintf("%s %s to %s %.0f @ %.2f: %#v", t.TransactionDate, i.Instruction, i.PositionEffect, i.Amount, i.Price, i.Instrument.String())
+	return fmt.Sprintf("%s %s to %s %.0f @ %s: %#v", t.TransactionDate, i.Instruction, i.PositionEffect, i.Amount, i.Price, i.Instrument.String())
 }
 
 func (i *Instrument) String() string {
@@ -109,24 +151,12 @@ func (i *Instrument) populateFromSymbol() error {
 		return nil
 	}
 
-	re := regexp.MustCompile(`([^_]+)_(\d{6})(P|C)(\d+(.\d+)?)`)
-	matches := re.FindStringSubmatch(string(i.Symbol))
-
-	if len(matches) < 6 {
-		return fmt.Errorf("couldn't parse option symbol")
-	}
-
-	if v, err := strconv.ParseFloat(matches[4], 32); err != nil {
-		return err
-	} else {
-		i.OptionStrikePrice = v
-	}
-
-	if t, err := time.Parse("010206", matches[2]); err != nil {
+	o, err := ParseOptionSymbol(string(i.Symbol))
+	if err != nil {
 		return err
-	} else {
-		i.OptionExpirationDate = Expiration(t)
 	}
+	i.OptionStrikePrice = o.Strike
+	i.OptionExpirationDate = Expiration(o.Expiration)
 
 	return nil
 }
@@ -0,0 +1,26 @@
+package tdam
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTDTimeUnmarshal(t *testing.T) {
+	var v struct {
+		Time *TDTime `json:"time"`
+	}
+	if err := json.Unmarshal([]byte(`{"time":"2021-03-12T14:30:00+0000"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2021, 3, 12, 14, 30, 0, 0, time.UTC); !time.Time(*v.Time).Equal(want) {
+		t.Errorf("time %v, want %v", time.Time(*v.Time), want)
+	}
+
+	for _, bad := range []string{`5`, `12345`, `""`, `"5"`, `true`} {
+		var tm TDTime
+		if err := json.Unmarshal([]byte(bad), &tm); err == nil {
+			t.Errorf("expected %s to fail as a time", bad)
+		}
+	}
+}
@@ -1,11 +1,9 @@
 package user
 
 import (
-	"crypto/tls"
+	"context"
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -25,6 +23,10 @@ func (t *TokenTimestamp) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+func (t TokenTimestamp) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + time.Time(t).Format(ttFormat) + `"`), nil
+}
+
 type StreamerInfo struct {
 	StreamerBinaryUrl string         `json:"streamerBinaryUrl"`
 	StreamerSocketUrl string         `json:"streamerSocketUrl"`
@@ -60,6 +62,14 @@ func (s *SubscriptionKeys) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+func (s SubscriptionKeys) MarshalJSON() ([]byte, error) {
+	keys := []map[string]string{}
+	for _, key := range s {
+		keys = append(keys, map[string]string{"key": key})
+	}
+	return json.Marshal(map[string][]map[string]string{"keys": keys})
+}
+
 type UPAccount struct {
 	AccountId         string         `json:"accountId"`
 	Description       string         `json:"description"`
@@ -74,15 +84,15 @@ type UPAccount struct {
 }
 
 type Authorizations struct {
-	Apex               bool   `json:"apex"`
-	LevelTwoQuotes     bool   `json:"levelTwoQuotes"`
-	StockTrading       bool   `json:"stockTrading"`
-	MarginTrading      bool   `json:"marginTrading"`
-	StreamingNews      bool   `json:"streamingNews"`
-	OptionTradingLevel string `json:"optionTradingLevel"` //"'COVERED' or 'FULL' or 'LONG' or 'SPREAD' or 'NONE'",
-	StreamerAccess     bool   `json:"streamerAccess"`
-	AdvancedMargin     bool   `json:"advancedMargin"`
-	ScottradeAccount   bool   `json:"scottradeAccount"`
+	Apex               bool                    `json:"apex"`
+	LevelTwoQuotes     bool                    `json:"levelTwoQuotes"`
+	StockTrading       bool                    `json:"stockTrading"`
+	MarginTrading      bool                    `json:"marginTrading"`
+	StreamingNews      bool                    `json:"streamingNews"`
+	OptionTradingLevel tdam.OptionTradingLevel `json:"optionTradingLevel"`
+	StreamerAccess     bool                    `json:"streamerAccess"`
+	AdvancedMargin     bool                    `json:"advancedMargin"`
+	ScottradeAccount   bool                    `json:"scottradeAccount"`
 }
 
 type Preferences struct {
@@ -120,39 +130,34 @@ type UserPrincipal struct {
 }
 
 func GetUserPrincipals(c *tdam.Client) (*UserPrincipal, error) {
-	token, err := c.TDAMToken()
-	if err != nil {
-		return nil, err
-	}
-
-	transport := &http.Transport{TLSClientConfig: &tls.Config{}}
-	client := &http.Client{Transport: transport}
+	return GetUserPrincipalsContext(context.Background(), c)
+}
 
-	req, err := http.NewRequest("GET", "https://api.tdameritrade.com/v1/userprincipals", nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-	options := req.URL.Query()
+func GetUserPrincipalsContext(ctx context.Context, c *tdam.Client) (*UserPrincipal, error) {
+	options := url.Values{}
 	options.Set("fields", "streamerSubscriptionKeys,streamerConnectionInfo")
 	//options.Add("fields", "streamerConnectionInfo")
-	req.URL.RawQuery = options.Encode()
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != 200 {
-		fmt.Printf("status %d: %s\n", resp.StatusCode, resp.Status)
-		body, _ := ioutil.ReadAll(resp.Body)
-		fmt.Println(string(body))
-	}
-	defer resp.Body.Close()
 
 	var u UserPrincipal
-	if err := json.NewDecoder(resp.Body).Decode(&u); err != nil {
+	if err := c.DoJSONContext(ctx, tdam.Request{
+		Endpoint:      "/v1/userprincipals",
+		Query:         options,
+		Authenticated: true,
+	}, &u); err != nil {
 		return nil, err
 	}
 
 	return &u, nil
 }
+
+// Authorize copies each account's option trading level onto the matching
+// tdam.Account, for Account.PreviewOrder to check orders against
+func (up *UserPrincipal) Authorize(accounts []*tdam.Account) {
+	for _, a := range accounts {
+		for _, upa := range up.Accounts {
+			if upa.AccountId == a.AccountId {
+				a.OptionTradingLevel = upa.Authorizations.OptionTradingLevel
+			}
+		}
+	}
+}
@@ -1,12 +1,6 @@
 package tdam
 
-import (
-	"crypto/tls"
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"net/http"
-)
+import "context"
 
 type Watchlist struct {
 	Name        string          `json:"name"`
@@ -33,33 +27,15 @@ type WatchlistInstrument struct {
 }
 
 func (c *Client) GetWatchlists() ([]Watchlist, error) {
-	token, err := c.TDAMToken()
-	if err != nil {
-		return nil, err
-	}
-
-	transport := &http.Transport{TLSClientConfig: &tls.Config{}}
-	client := &http.Client{Transport: transport}
-
-	req, err := http.NewRequest("GET", "https://api.tdameritrade.com/v1/accounts/watchlists", nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != 200 {
-		fmt.Printf("status %d: %s\n", resp.StatusCode, resp.Status)
-		body, _ := ioutil.ReadAll(resp.Body)
-		fmt.Println(string(body))
-	}
-	defer resp.Body.Close()
+	return c.GetWatchlistsContext(context.Background())
+}
 
+func (c *Client) GetWatchlistsContext(ctx context.Context) ([]Watchlist, error) {
 	var watchlists []Watchlist
-	if err := json.NewDecoder(resp.Body).Decode(&watchlists); err != nil {
+	if err := c.DoJSONContext(ctx, Request{
+		Endpoint:      "/v1/accounts/watchlists",
+		Authenticated: true,
+	}, &watchlists); err != nil {
 		return nil, err
 	}
 